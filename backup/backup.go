@@ -0,0 +1,140 @@
+// Package backup keeps timestamped snapshots of todo lists somewhere off
+// this machine. A Remote stores, lists and deletes the snapshots; Google
+// Drive, WebDAV servers, S3-compatible object storage, a plain directory
+// and a git repository each have one.
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-bubbletea/config"
+)
+
+// ErrNotFound is returned by Get and Delete for a version that is gone
+var ErrNotFound = errors.New("backup not found")
+
+// Version is one snapshot of a list kept by a Remote
+type Version struct {
+	// ID is what the remote knows the snapshot by. Put fills it in.
+	ID string
+	// Name is how the snapshot is shown to the user
+	Name string
+	List string
+	// Time is when the snapshot was taken
+	Time time.Time
+	// Todos is how many todos it holds, or -1 if unknown
+	Todos int
+	Size  int64
+}
+
+// Remote is somewhere snapshots of lists are kept
+type Remote interface {
+	// Put stores data as the snapshot v describes and returns it as
+	// stored
+	Put(v Version, data []byte) (Version, error)
+	// Get returns the contents of a snapshot
+	Get(v Version) ([]byte, error)
+	// List returns the snapshots of list, newest first
+	List(list string) ([]Version, error)
+	// Delete removes a snapshot for good
+	Delete(v Version) error
+	// String describes where the snapshots are kept, for messages
+	String() string
+}
+
+// timeLayout names snapshots after when they were taken. It has a fixed
+// width, so names sort by time.
+const timeLayout = "20060102T150405.000000000Z"
+
+// ObjectName returns the name the file-like remotes keep v under: a
+// directory for each list holding one file per snapshot, named after its
+// time and number of todos
+func ObjectName(v Version) string {
+	return fmt.Sprintf("%s/%s_%d.json", v.List, v.Time.UTC().Format(timeLayout), v.Todos)
+}
+
+// ParseObjectName reads a name made by ObjectName back into a version. It
+// reports false for files that are not snapshots.
+func ParseObjectName(name string) (Version, bool) {
+	list, file := path.Split(name)
+	list = strings.TrimSuffix(list, "/")
+	stem, ok := strings.CutSuffix(file, ".json")
+	if !ok || list == "" || strings.Contains(list, "/") {
+		return Version{}, false
+	}
+	taken, count, ok := strings.Cut(stem, "_")
+	if !ok {
+		return Version{}, false
+	}
+	t, err := time.Parse(timeLayout, taken)
+	if err != nil {
+		return Version{}, false
+	}
+	todos, err := strconv.Atoi(count)
+	if err != nil {
+		return Version{}, false
+	}
+	return Version{ID: name, Name: name, List: list, Time: t, Todos: todos}, true
+}
+
+// sortNewest orders versions newest first
+func sortNewest(versions []Version) {
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Time.After(versions[j].Time) })
+}
+
+// Prune returns the versions keep does not cover at now, oldest first. The
+// newest version is always kept. Days and months are counted in now's
+// location.
+func Prune(versions []Version, keep config.BackupRetention, now time.Time) []Version {
+	sorted := append([]Version(nil), versions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.After(sorted[j].Time) })
+
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	firstDay := today.AddDate(0, 0, 1-keep.Daily)
+	firstMonth := time.Date(now.Year(), now.Month()-time.Month(keep.Monthly-1), 1, 0, 0, 0, 0, loc)
+
+	days := map[string]bool{}
+	months := map[string]bool{}
+	var drop []Version
+	for i, v := range sorted {
+		t := v.Time.In(loc)
+		day, month := t.Format("2006-01-02"), t.Format("2006-01")
+
+		kept := i < max(keep.Last, 1)
+		if keep.Daily > 0 && !t.Before(firstDay) && !days[day] {
+			days[day] = true
+			kept = true
+		}
+		if keep.Monthly > 0 && !t.Before(firstMonth) && !months[month] {
+			months[month] = true
+			kept = true
+		}
+		if !kept {
+			drop = append(drop, v)
+		}
+	}
+
+	for i, j := 0, len(drop)-1; i < j; i, j = i+1, j-1 {
+		drop[i], drop[j] = drop[j], drop[i]
+	}
+	return drop
+}
+
+// Latest returns the newest of versions taken no later than t
+func Latest(versions []Version, t time.Time) (Version, bool) {
+	var latest Version
+	found := false
+	for _, v := range versions {
+		if !v.Time.After(t) && (!found || v.Time.After(latest.Time)) {
+			latest, found = v, true
+		}
+	}
+	return latest, found
+}
@@ -0,0 +1,86 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"todo-bubbletea/config"
+)
+
+// hourly returns a version every hour going back from now, named after
+// their time
+func hourly(now time.Time, hours int) []Version {
+	backups := make([]Version, hours)
+	for i := range backups {
+		t := now.Add(-time.Duration(i) * time.Hour)
+		backups[i] = Version{ID: t.Format(time.DateTime), Time: t}
+	}
+	return backups
+}
+
+func ids(backups []Version) map[string]bool {
+	set := map[string]bool{}
+	for _, b := range backups {
+		set[b.ID] = true
+	}
+	return set
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 30, 0, 0, time.UTC)
+	backups := hourly(now, 400*24)
+	drop := ids(Prune(backups, config.BackupRetention{Last: 5, Daily: 7, Monthly: 12}, now))
+
+	var kept []string
+	for _, b := range backups {
+		if !drop[b.ID] {
+			kept = append(kept, b.ID)
+		}
+	}
+	want := []string{
+		// The last 5
+		"2026-10-16 12:30:00", "2026-10-16 11:30:00", "2026-10-16 10:30:00", "2026-10-16 09:30:00", "2026-10-16 08:30:00",
+		// The newest of each of the 6 days before today
+		"2026-10-15 23:30:00", "2026-10-14 23:30:00", "2026-10-13 23:30:00", "2026-10-12 23:30:00", "2026-10-11 23:30:00", "2026-10-10 23:30:00",
+		// The newest of each of the 11 months before this one
+		"2026-09-30 23:30:00", "2026-08-31 23:30:00", "2026-07-31 23:30:00", "2026-06-30 23:30:00", "2026-05-31 23:30:00", "2026-04-30 23:30:00",
+		"2026-03-31 23:30:00", "2026-02-28 23:30:00", "2026-01-31 23:30:00", "2025-12-31 23:30:00", "2025-11-30 23:30:00",
+	}
+	if len(kept) != len(want) {
+		t.Fatalf("kept %d backups %v, want %d", len(kept), kept, len(want))
+	}
+	for i := range want {
+		if kept[i] != want[i] {
+			t.Errorf("kept[%d] = %s, want %s", i, kept[i], want[i])
+		}
+	}
+
+	all := Prune(backups, config.BackupRetention{Last: 5, Daily: 7, Monthly: 12}, now)
+	if !all[0].Time.Before(all[len(all)-1].Time) {
+		t.Error("Prune did not return the oldest backup first")
+	}
+}
+
+func TestPruneKeepsNewest(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	drop := Prune(hourly(now, 3), config.BackupRetention{}, now)
+	if len(drop) != 2 || ids(drop)[now.Format(time.DateTime)] {
+		t.Errorf("Prune with no retention dropped %v, want all but the newest", drop)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	backups := hourly(now, 48)
+
+	got, ok := Latest(backups, time.Date(2026, 10, 15, 23, 59, 59, 0, time.UTC))
+	if !ok || got.ID != "2026-10-15 23:00:00" {
+		t.Errorf("Latest at the end of Oct 15 = %v, %v", got.ID, ok)
+	}
+	if got, ok := Latest(backups, now.Add(time.Hour)); !ok || got.ID != now.Format(time.DateTime) {
+		t.Errorf("Latest after the newest = %v, %v", got.ID, ok)
+	}
+	if _, ok := Latest(backups, now.Add(-48*time.Hour)); ok {
+		t.Error("Latest found a backup before the oldest")
+	}
+}
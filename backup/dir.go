@@ -0,0 +1,82 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"todo-bubbletea/todo"
+)
+
+// Dir keeps snapshots as files in a local directory, such as a mounted
+// network share or a folder another program syncs
+type Dir struct {
+	Path string
+}
+
+// Put writes the snapshot to a new file
+func (d Dir) Put(v Version, data []byte) (Version, error) {
+	v.ID = ObjectName(v)
+	v.Name = v.ID
+	v.Size = int64(len(data))
+	file := d.file(v)
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return Version{}, err
+	}
+	if err := todo.WriteFileAtomic(file, data, 0600); err != nil {
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// Get reads a snapshot's file
+func (d Dir) Get(v Version) ([]byte, error) {
+	data, err := os.ReadFile(d.file(v))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, v.ID)
+	}
+	return data, err
+}
+
+// List reads the directory of list
+func (d Dir) List(list string) ([]Version, error) {
+	entries, err := os.ReadDir(filepath.Join(d.Path, list))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	for _, e := range entries {
+		v, ok := ParseObjectName(list + "/" + e.Name())
+		if !ok || !e.Type().IsRegular() {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			v.Size = info.Size()
+		}
+		versions = append(versions, v)
+	}
+	sortNewest(versions)
+	return versions, nil
+}
+
+// Delete removes a snapshot's file
+func (d Dir) Delete(v Version) error {
+	err := os.Remove(d.file(v))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, v.ID)
+	}
+	return err
+}
+
+func (d Dir) String() string {
+	return d.Path
+}
+
+func (d Dir) file(v Version) string {
+	return filepath.Join(d.Path, filepath.FromSlash(v.ID))
+}
@@ -0,0 +1,21 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "backups")
+	testRemote(t, Dir{Path: dir})
+
+	// Other files in the directory are left alone
+	if err := os.WriteFile(filepath.Join(dir, "default", "README"), []byte("hi"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := Dir{Path: dir}.List("default")
+	if err != nil || len(versions) != 2 {
+		t.Errorf("List with a stray file = %+v, %v", versions, err)
+	}
+}
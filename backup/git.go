@@ -0,0 +1,106 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// fallbackIdentity is who commits when git has no user configured, as on
+// a fresh server or in a container
+var fallbackIdentity = []string{"-c", "user.name=todo", "-c", "user.email=todo@localhost"}
+
+// Git keeps snapshots as files in a git repository, committing each one
+// added or removed. With Push every commit is pushed to the branch's
+// upstream, so the repository can live on any git host.
+type Git struct {
+	Dir
+	Push bool
+
+	// config is passed to every git command
+	config []string
+}
+
+// OpenGit returns the repository at path, creating it if it does not
+// exist yet. Commits use the git identity configured for the repository
+// or the user, or fallbackIdentity when there is none.
+func OpenGit(path string, push bool) (*Git, error) {
+	g := &Git{Dir: Dir{Path: path}, Push: push}
+	if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+		if err := os.MkdirAll(path, 0700); err != nil {
+			return nil, err
+		}
+		if err := g.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+	}
+	// git var fails the way git commit would when it cannot tell who
+	// is committing
+	if g.git("var", "GIT_AUTHOR_IDENT") != nil || g.git("var", "GIT_COMMITTER_IDENT") != nil {
+		g.config = fallbackIdentity
+	}
+	return g, nil
+}
+
+// Put writes the snapshot and commits it
+func (g *Git) Put(v Version, data []byte) (Version, error) {
+	v, err := g.Dir.Put(v, data)
+	if err != nil {
+		return Version{}, err
+	}
+	if err := g.git("add", "--", v.ID); err != nil {
+		return Version{}, err
+	}
+	if err := g.commit(fmt.Sprintf("Back up %s (%d todos)", v.List, v.Todos)); err != nil {
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// Delete removes the snapshot from the repository. It stays in the
+// history.
+func (g *Git) Delete(v Version) error {
+	if err := g.Dir.Delete(v); err != nil {
+		return err
+	}
+	if err := g.git("add", "--all", "--", v.ID); err != nil {
+		return err
+	}
+	return g.commit("Remove old backup " + v.ID)
+}
+
+func (g *Git) String() string {
+	return "git repository " + g.Path
+}
+
+// commit records what is staged, pushing it if asked to
+func (g *Git) commit(message string) error {
+	if err := g.git("commit", "--quiet", "--no-verify", "-m", message); err != nil {
+		return err
+	}
+	if g.Push {
+		if err := g.git("push", "--quiet"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// git runs a git command in the repository
+func (g *Git) git(args ...string) error {
+	cmd := exec.Command("git", slices.Concat(g.config, args)...)
+	cmd.Dir = g.Path
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
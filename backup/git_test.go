@@ -0,0 +1,103 @@
+package backup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGit(t *testing.T) {
+	noIdentity(t)
+
+	// Pushing goes to a bare repository standing in for the git host
+	upstream := filepath.Join(t.TempDir(), "upstream.git")
+	run(t, "", "git", "init", "--quiet", "--bare", upstream)
+	dir := filepath.Join(t.TempDir(), "backups")
+	run(t, "", "git", "clone", "--quiet", upstream, dir)
+
+	g, err := OpenGit(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testRemote(t, g)
+
+	// Four snapshots put and one deleted, each a commit, all pushed
+	log := run(t, "", "git", "--git-dir", upstream, "log", "--format=%s")
+	if n := len(strings.Split(strings.TrimSpace(log), "\n")); n != 5 {
+		t.Errorf("upstream has %d commits, want 5:\n%s", n, log)
+	}
+	if status := run(t, dir, "git", "status", "--porcelain"); status != "" {
+		t.Errorf("working tree not clean:\n%s", status)
+	}
+
+	// A path with no repository gets one
+	fresh := filepath.Join(t.TempDir(), "fresh")
+	g, err = OpenGit(fresh, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Put(Version{List: "default", Todos: 1}, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if out := run(t, fresh, "git", "log", "--format=%s"); !strings.HasPrefix(out, "Back up default") {
+		t.Errorf("log of the new repository = %q", out)
+	}
+	if author := run(t, fresh, "git", "log", "-1", "--format=%an <%ae>"); author != "todo <todo@localhost>\n" {
+		t.Errorf("committed as %q without a git identity", author)
+	}
+}
+
+func TestGitKeepsIdentity(t *testing.T) {
+	noIdentity(t)
+	dir := filepath.Join(t.TempDir(), "backups")
+	run(t, "", "git", "init", "--quiet", dir)
+	run(t, dir, "git", "config", "user.name", "Alice")
+	run(t, dir, "git", "config", "user.email", "alice@example.com")
+
+	g, err := OpenGit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Put(Version{List: "default", Todos: 1}, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if author := run(t, dir, "git", "log", "-1", "--format=%an <%ae>"); author != "Alice <alice@example.com>\n" {
+		t.Errorf("committed as %q, want the configured identity", author)
+	}
+}
+
+// noIdentity skips the test without git, and otherwise leaves git with
+// no user to commit as, like on a machine where it was never set up
+func noIdentity(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	home := t.TempDir()
+	global := filepath.Join(home, "gitconfig")
+	// Stop git guessing a name and address from the system
+	if err := os.WriteFile(global, []byte("[user]\n\tuseConfigOnly = true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", global)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL", "EMAIL"} {
+		t.Setenv(env, "")
+		os.Unsetenv(env)
+	}
+}
+
+func run(t *testing.T, dir string, name string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s %v: %v\n%s", name, args, err, out)
+	}
+	return string(out)
+}
@@ -0,0 +1,40 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// httpClient is shared by the remotes that are not given their own, so
+// connections are reused
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// StatusError reports an unexpected response from a WebDAV or S3 server
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s (Status: %d)", e.Message, e.Code)
+}
+
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	message := strings.TrimSpace(string(body))
+	if message == "" || strings.HasPrefix(message, "<") {
+		message = http.StatusText(resp.StatusCode)
+	}
+	return &StatusError{Code: resp.StatusCode, Message: message}
+}
+
+// do sends req with client, or httpClient if it is nil
+func do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if client == nil {
+		client = httpClient
+	}
+	return client.Do(req)
+}
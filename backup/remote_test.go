@@ -0,0 +1,100 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// testRemote checks that r stores, lists, fetches and deletes snapshots,
+// keeping lists apart
+func testRemote(t *testing.T, r Remote) {
+	t.Helper()
+
+	if versions, err := r.List("default"); err != nil || len(versions) != 0 {
+		t.Fatalf("List on an empty remote = %v, %v", versions, err)
+	}
+
+	start := time.Date(2026, 10, 16, 9, 0, 0, 123456789, time.UTC)
+	var put []Version
+	for i := range 3 {
+		data := []byte(fmt.Sprintf(`{"todos":[],"n":%d}`, i))
+		v, err := r.Put(Version{List: "default", Time: start.Add(time.Duration(i) * time.Hour), Todos: i}, data)
+		if err != nil {
+			t.Fatalf("Put %d: %v", i, err)
+		}
+		if v.ID == "" || v.List != "default" || v.Todos != i || !v.Time.Equal(start.Add(time.Duration(i)*time.Hour)) {
+			t.Errorf("Put %d returned %+v", i, v)
+		}
+		put = append(put, v)
+	}
+	if _, err := r.Put(Version{List: "work", Time: start, Todos: 7}, []byte(`{"todos":[]}`)); err != nil {
+		t.Fatalf("Put in another list: %v", err)
+	}
+
+	versions, err := r.List("default")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("List returned %d versions %+v, want 3", len(versions), versions)
+	}
+	for i, v := range versions {
+		want := put[2-i]
+		if v.ID != want.ID || !v.Time.Equal(want.Time) || v.Todos != want.Todos || v.List != "default" {
+			t.Errorf("List()[%d] = %+v, want %+v", i, v, want)
+		}
+		if v.Size != int64(len(`{"todos":[],"n":0}`)) {
+			t.Errorf("List()[%d].Size = %d", i, v.Size)
+		}
+	}
+
+	data, err := r.Get(versions[0])
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(data) != `{"todos":[],"n":2}` {
+		t.Errorf("Get returned %s", data)
+	}
+
+	if err := r.Delete(versions[1]); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := r.Get(versions[1]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: %v, want ErrNotFound", err)
+	}
+	if err := r.Delete(versions[1]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete of a deleted version: %v, want ErrNotFound", err)
+	}
+	if versions, err = r.List("default"); err != nil || len(versions) != 2 {
+		t.Fatalf("List after Delete = %+v, %v", versions, err)
+	}
+	if versions[0].ID != put[2].ID || versions[1].ID != put[0].ID {
+		t.Errorf("List after Delete = %+v", versions)
+	}
+
+	if work, err := r.List("work"); err != nil || len(work) != 1 || work[0].Todos != 7 {
+		t.Errorf("List of the other list = %+v, %v", work, err)
+	}
+	if r.String() == "" {
+		t.Error("String is empty")
+	}
+}
+
+func TestObjectName(t *testing.T) {
+	v := Version{List: "work", Time: time.Date(2026, 10, 16, 9, 12, 40, 5, time.FixedZone("CEST", 2*3600)), Todos: 12}
+	name := ObjectName(v)
+	if name != "work/20261016T071240.000000005Z_12.json" {
+		t.Errorf("ObjectName = %s", name)
+	}
+	got, ok := ParseObjectName(name)
+	if !ok || got.ID != name || got.List != "work" || !got.Time.Equal(v.Time) || got.Todos != 12 {
+		t.Errorf("ParseObjectName(%s) = %+v, %v", name, got, ok)
+	}
+	for _, bad := range []string{"work/notes.txt", "work/20261016T071240Z_12.json", "20261016T071240.000000005Z_12.json", "a/b/20261016T071240.000000005Z_12.json"} {
+		if _, ok := ParseObjectName(bad); ok {
+			t.Errorf("ParseObjectName(%s) accepted it", bad)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 keeps snapshots as objects in a bucket of Amazon S3 or a compatible
+// store such as MinIO, Backblaze B2 or Cloudflare R2. Requests are
+// path-style and signed with AWS Signature Version 4.
+type S3 struct {
+	// Endpoint is the server's base URL, such as
+	// https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+	Endpoint string
+	Bucket   string
+	Region   string
+	// Prefix is put in front of every key, so the bucket can hold other
+	// things too
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	// HTTP sends the requests; nil means a shared default client
+	HTTP *http.Client
+}
+
+// Put uploads the snapshot as a new object
+func (s *S3) Put(v Version, data []byte) (Version, error) {
+	v.ID = ObjectName(v)
+	v.Name = v.ID
+	v.Size = int64(len(data))
+	resp, err := s.request(http.MethodPut, s.key(v.ID), nil, data, http.Header{"Content-Type": {"application/json"}})
+	if err != nil {
+		return Version{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Version{}, fmt.Errorf("uploading %s: %w", v.ID, s3Error(resp))
+	}
+	return v, nil
+}
+
+// Get downloads a snapshot's object
+func (s *S3) Get(v Version) ([]byte, error) {
+	resp, err := s.request(http.MethodGet, s.key(v.ID), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, v.ID)
+	}
+	return nil, fmt.Errorf("downloading %s: %w", v.ID, s3Error(resp))
+}
+
+// listBucketResult is the part of a ListObjectsV2 answer List reads
+type listBucketResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List lists the objects under the prefix of list, a page at a time
+func (s *S3) List(list string) ([]Version, error) {
+	prefix := s.key(list + "/")
+	var versions []Version
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.request(http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page listBucketResult
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("listing %s: %w", s, s3Error(resp))
+		} else if err = xml.NewDecoder(resp.Body).Decode(&page); err != nil {
+			err = fmt.Errorf("parsing listing of %s: %w", s, err)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range page.Contents {
+			v, ok := ParseObjectName(strings.TrimPrefix(obj.Key, s.key("")))
+			if !ok {
+				continue
+			}
+			v.Size = obj.Size
+			versions = append(versions, v)
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+	sortNewest(versions)
+	return versions, nil
+}
+
+// Delete removes a snapshot's object. S3 answers a delete the same
+// whether or not there was an object, so Delete first asks whether there
+// is one, and also takes a 404 from services that give it.
+func (s *S3) Delete(v Version) error {
+	resp, err := s.request(http.MethodHead, s.key(v.ID), nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, v.ID)
+	default:
+		return fmt.Errorf("deleting %s: %s", v.ID, resp.Status)
+	}
+
+	resp, err = s.request(http.MethodDelete, s.key(v.ID), nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, v.ID)
+	}
+	return fmt.Errorf("deleting %s: %w", v.ID, s3Error(resp))
+}
+
+func (s *S3) String() string {
+	return "s3://" + path.Join(s.Bucket, s.Prefix)
+}
+
+// key returns the object key of name
+func (s *S3) key(name string) string {
+	if s.Prefix == "" {
+		return name
+	}
+	return strings.Trim(s.Prefix, "/") + "/" + name
+}
+
+// request sends a signed request for the object key, or for the bucket
+// itself if key is empty
+func (s *S3) request(method, key string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	u, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	u.Path += "/" + s.Bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, values := range header {
+		req.Header[k] = values
+	}
+	payloadHash := hashHex(body)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	signV4(req, payloadHash, s.AccessKey, s.SecretKey, region, "s3", time.Now())
+	return do(s.HTTP, req)
+}
+
+// s3Error reads the code and message out of an S3 error response
+func s3Error(resp *http.Response) error {
+	var answer struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if xml.Unmarshal(body, &answer) != nil || answer.Code == "" {
+		return &StatusError{Code: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+	message := answer.Code
+	if answer.Message != "" {
+		message += ": " + answer.Message
+	}
+	return &StatusError{Code: resp.StatusCode, Message: message}
+}
+
+// signV4 signs req for service in region with AWS Signature Version 4 as
+// of t, given the hex SHA-256 of its body. The host, the content type and
+// any x-amz-* headers are signed.
+func signV4(req *http.Request, payloadHash, accessKey, secretKey, region, service string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	day := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for key, values := range req.Header {
+		key = strings.ToLower(key)
+		if key == "content-type" || strings.HasPrefix(key, "x-amz-") {
+			headers[key] = strings.Join(values, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.Join(strings.Fields(headers[name]), " "))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.EscapedPath()),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalURI encodes each segment of an escaped path the way SigV4
+// wants, which differs from Go's escaping in a few characters
+func canonicalURI(escaped string) string {
+	if escaped == "" {
+		return "/"
+	}
+	segments := strings.Split(escaped, "/")
+	for i, seg := range segments {
+		if raw, err := url.PathUnescape(seg); err == nil {
+			segments[i] = uriEncode(raw)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery sorts the query parameters and encodes them for SigV4
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters of
+// RFC 3986
+func uriEncode(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,186 @@
+package backup
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The example request from AWS's Signature Version 4 documentation
+func TestSignV4(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signV4(req, hashHex(nil), "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "iam",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %s\nwant %s", got, want)
+	}
+}
+
+// fakeS3 is an in-memory stand-in for a path-style S3 server with one
+// bucket. It checks every request's signature and payload hash, and lists
+// two objects a page so that paging is exercised.
+type fakeS3 struct {
+	bucket            string
+	access, secretKey string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	deletes int // DELETE requests answered
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := f.verify(r, body); err != "" {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, "<Error><Code>SignatureDoesNotMatch</Code><Message>%s</Message></Error>", err)
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "<Error><Code>NoSuchBucket</Code></Error>")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, r)
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodHead:
+		if _, ok := f.objects[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodDelete:
+		f.deletes++
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) deleteCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.deletes
+}
+
+// list answers ListObjectsV2, continuing after the key in the token
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("list-type") != "2" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, q.Get("prefix")) && key > q.Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	type object struct {
+		Key  string
+		Size int
+	}
+	var result struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []object
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}
+	for i, key := range keys {
+		if i == 2 {
+			result.IsTruncated = true
+			result.NextContinuationToken = keys[1]
+			break
+		}
+		result.Contents = append(result.Contents, object{key, len(f.objects[key])})
+	}
+	xml.NewEncoder(w).Encode(result)
+}
+
+// verify signs the request again with the date it was signed at, and
+// returns what is wrong with it
+func (f *fakeS3) verify(r *http.Request, body []byte) string {
+	if r.Header.Get("X-Amz-Content-Sha256") != hashHex(body) {
+		return "payload hash does not match the body"
+	}
+	signedAt, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return "bad X-Amz-Date"
+	}
+	check, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	for key, values := range r.Header {
+		check.Header[key] = values
+	}
+	check.Header.Del("Authorization")
+	signV4(check, hashHex(body), f.access, f.secretKey, "us-east-1", "s3", signedAt)
+	if got, want := r.Header.Get("Authorization"), check.Header.Get("Authorization"); got != want {
+		return fmt.Sprintf("got %s, want %s", got, want)
+	}
+	return ""
+}
+
+func TestS3(t *testing.T) {
+	fake := &fakeS3{bucket: "todo", access: "minio", secretKey: "minio123", objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	s := &S3{Endpoint: srv.URL, Bucket: "todo", Prefix: "laptop", AccessKey: "minio", SecretKey: "minio123"}
+	testRemote(t, s)
+	for key := range fake.objects {
+		if !strings.HasPrefix(key, "laptop/") {
+			t.Errorf("object %s is outside the prefix", key)
+		}
+	}
+
+	wrong := *s
+	wrong.SecretKey = "guess"
+	if _, err := wrong.List("default"); err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("List with a wrong secret key: %v", err)
+	}
+	gone := Version{ID: ObjectName(Version{List: "default", Time: time.Now(), Todos: 1})}
+	deletes := fake.deleteCount()
+	if err := s.Delete(gone); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete of a missing snapshot: %v, want ErrNotFound", err)
+	}
+	if fake.deleteCount() != deletes {
+		t.Error("Delete of a missing snapshot sent a DELETE")
+	}
+
+	missing := *s
+	missing.Bucket = "other"
+	if _, err := missing.List("default"); err == nil || !strings.Contains(err.Error(), "NoSuchBucket") {
+		t.Errorf("List of a missing bucket: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// WebDAV keeps snapshots in a collection on a WebDAV server, such as
+// Nextcloud, ownCloud or Apache with mod_dav
+type WebDAV struct {
+	// URL is the collection the snapshots go in. It is created if need
+	// be, but its parent has to exist.
+	URL      string
+	User     string
+	Password string
+	// HTTP sends the requests; nil means a shared default client
+	HTTP *http.Client
+}
+
+// Put creates the collections the snapshot goes in and uploads it
+func (w *WebDAV) Put(v Version, data []byte) (Version, error) {
+	v.ID = ObjectName(v)
+	v.Name = v.ID
+	v.Size = int64(len(data))
+	for _, dir := range []string{"", v.List + "/"} {
+		if err := w.mkcol(dir); err != nil {
+			return Version{}, err
+		}
+	}
+
+	resp, err := w.request(http.MethodPut, v.ID, bytes.NewReader(data), http.Header{"Content-Type": {"application/json"}})
+	if err != nil {
+		return Version{}, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return v, nil
+	}
+	return Version{}, fmt.Errorf("uploading %s: %w", v.ID, statusError(resp))
+}
+
+// mkcol creates the collection at dir, which is fine if it exists already
+func (w *WebDAV) mkcol(dir string) error {
+	resp, err := w.request("MKCOL", dir, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK, http.StatusMethodNotAllowed:
+		// 405 is the answer for a collection that exists
+		return nil
+	}
+	return fmt.Errorf("creating collection %s: %w", w.url(dir), statusError(resp))
+}
+
+// Get downloads a snapshot
+func (w *WebDAV) Get(v Version) ([]byte, error) {
+	resp, err := w.request(http.MethodGet, v.ID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, v.ID)
+	}
+	return nil, fmt.Errorf("downloading %s: %w", v.ID, statusError(resp))
+}
+
+// multistatus is the part of a PROPFIND answer List reads
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Length string `xml:"prop>getcontentlength"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><getcontentlength/></prop></propfind>`
+
+// List asks for the members of the collection of list
+func (w *WebDAV) List(list string) ([]Version, error) {
+	resp, err := w.request("PROPFIND", list+"/", strings.NewReader(propfindBody), http.Header{
+		"Depth":        {"1"},
+		"Content-Type": {"application/xml; charset=utf-8"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusMultiStatus:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("listing %s: %w", w.url(list+"/"), statusError(resp))
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("parsing listing of %s: %w", w.url(list+"/"), err)
+	}
+	var versions []Version
+	for _, r := range ms.Responses {
+		href, err := url.PathUnescape(r.Href)
+		if err != nil || strings.HasSuffix(href, "/") {
+			continue
+		}
+		v, ok := ParseObjectName(list + "/" + path.Base(href))
+		if !ok {
+			continue
+		}
+		for _, p := range r.Propstat {
+			if n, err := strconv.ParseInt(p.Length, 10, 64); err == nil {
+				v.Size = n
+			}
+		}
+		versions = append(versions, v)
+	}
+	sortNewest(versions)
+	return versions, nil
+}
+
+// Delete removes a snapshot from the server
+func (w *WebDAV) Delete(v Version) error {
+	resp, err := w.request(http.MethodDelete, v.ID, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, v.ID)
+	}
+	return fmt.Errorf("deleting %s: %w", v.ID, statusError(resp))
+}
+
+func (w *WebDAV) String() string {
+	if u, err := url.Parse(w.URL); err == nil {
+		return u.Redacted()
+	}
+	return w.URL
+}
+
+// url returns the address of name inside the collection
+func (w *WebDAV) url(name string) string {
+	return strings.TrimSuffix(w.URL, "/") + "/" + name
+}
+
+func (w *WebDAV) request(method, name string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, w.url(name), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if w.User != "" {
+		req.SetBasicAuth(w.User, w.Password)
+	}
+	return do(w.HTTP, req)
+}
@@ -8,11 +8,11 @@ go mod tidy
 
 # Build basic version
 echo "🔨 Building basic version..."
-go build -o todo-basic main.go
+go build -o todo-basic ./cmd/todo-basic
 
 # Build advanced version
 echo "🔨 Building advanced version..."
-go build -o todo-advanced advanced.go
+go build -o todo-advanced ./cmd/todo-advanced
 
 echo "✅ Build complete!"
 echo ""
@@ -0,0 +1,354 @@
+// Package client talks to a todo server such as 'todo serve'. It fetches
+// and replaces whole lists, follows the change feed and syncs a local store
+// with the server. The CLI and the TUI share it.
+package client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-bubbletea/config"
+	"todo-bubbletea/server"
+	"todo-bubbletea/todo"
+)
+
+// Config is a todo server and the credentials to use with it
+type Config struct {
+	ServerURL string
+	Username  string
+	Password  string
+	// Token is sent as a bearer token instead of basic auth
+	Token string
+	// HTTP carries the server's TLS settings; nil means a shared client
+	HTTP *http.Client
+	// Notify, if set, is told when a request is retried
+	Notify func(message string)
+}
+
+// ForRemote returns the configuration for talking to remote, with secret
+// as its password or bearer token
+func ForRemote(remote config.Remote, secret string) (Config, error) {
+	c := Config{ServerURL: remote.URL, Username: remote.User}
+	switch {
+	case remote.Bearer():
+		c.Token = secret
+	case remote.User != "":
+		c.Password = secret
+	}
+
+	conf, err := remote.TLSConfig()
+	if err != nil || conf == nil {
+		return c, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = conf
+	c.HTTP = &http.Client{Timeout: httpClient.Timeout, Transport: transport}
+	return c, nil
+}
+
+// endpoint returns the URL of path on the server
+func (c Config) endpoint(path string) string {
+	return strings.TrimSuffix(c.ServerURL, "/") + path
+}
+
+func (c Config) notify(message string) {
+	if c.Notify != nil {
+		c.Notify(message)
+	}
+}
+
+var (
+	// ErrNoChangeFeed reports a server that only serves whole lists
+	ErrNoChangeFeed = errors.New("server has no change feed")
+	// ErrCursorExpired reports a cursor the server no longer accepts
+	ErrCursorExpired = errors.New("sync cursor has expired")
+	// ErrServerChanged reports a write refused because the server's todos
+	// changed after they were last fetched
+	ErrServerChanged = errors.New("todos changed on the server")
+	// ErrUnreachable reports a server that could not be reached even after
+	// retrying
+	ErrUnreachable = errors.New("server unreachable")
+)
+
+// Fetch downloads the server's todo list and its ETag
+func Fetch(c Config) (*todo.TodoList, string, error) {
+	resp, err := request(c, "GET", server.APIPath, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", statusError(resp)
+	}
+
+	todoList := todo.NewTodoList()
+	if err := json.NewDecoder(resp.Body).Decode(todoList); err != nil {
+		return nil, "", fmt.Errorf("parsing response: %w", err)
+	}
+	return todoList, resp.Header.Get("ETag"), nil
+}
+
+// Replace replaces the server's todo list with todoList, sending header
+// for preconditions, and returns the list's new ETag. A failed
+// precondition is ErrServerChanged.
+func Replace(c Config, todoList *todo.TodoList, header http.Header) (string, error) {
+	resp, err := request(c, "POST", server.APIPath, todoList, header)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return resp.Header.Get("ETag"), nil
+	case http.StatusPreconditionFailed:
+		return "", ErrServerChanged
+	}
+	return "", statusError(resp)
+}
+
+// IfMatch returns the header that makes a write fail with 412 Precondition
+// Failed unless the server's list still has etag, or nil without one
+func IfMatch(etag string) http.Header {
+	if etag == "" {
+		return nil
+	}
+	return http.Header{"If-Match": {etag}}
+}
+
+// FetchChanges asks the server for the todos written since cursor, or for
+// every todo if cursor is empty. It also returns the list's ETag.
+func FetchChanges(c Config, cursor string) (*server.Changes, string, error) {
+	path := server.ChangesPath
+	if cursor != "" {
+		path += "?since=" + url.QueryEscape(cursor)
+	}
+	resp, err := request(c, "GET", path, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, "", ErrNoChangeFeed
+	case http.StatusGone:
+		return nil, "", ErrCursorExpired
+	default:
+		return nil, "", statusError(resp)
+	}
+
+	var feed server.Changes
+	if err := json.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, "", fmt.Errorf("parsing response: %w", err)
+	}
+	if feed.Changes == nil {
+		// Not a change feed, such as a server answering every GET with
+		// the whole list
+		return nil, "", ErrNoChangeFeed
+	}
+	return &feed, resp.Header.Get("ETag"), nil
+}
+
+// PushChanges sends the todos changed locally since the server's cursor,
+// returning its new cursor and ETag
+func PushChanges(c Config, cursor string, changes []todo.Todo) (string, string, error) {
+	resp, err := request(c, "POST", server.ChangesPath, server.Changes{Cursor: cursor, Changes: changes}, nil)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusConflict:
+		return "", "", ErrServerChanged
+	default:
+		return "", "", statusError(resp)
+	}
+
+	var result server.Changes
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", "", fmt.Errorf("parsing response: %w", err)
+	}
+	return result.Cursor, resp.Header.Get("ETag"), nil
+}
+
+// httpClient is shared by servers without TLS settings so connections are
+// reused
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Transient failures are retried up to retryAttempts times in all, waiting
+// around retryBase, then twice that, and so on up to retryMax
+const (
+	retryAttempts = 4
+	retryBase     = 500 * time.Millisecond
+	retryMax      = 8 * time.Second
+)
+
+// request sends a request for path to the server, with body as JSON unless
+// it is nil and with any extra header. Network errors and 429, 502, 503
+// and 504 responses are retried with exponential backoff; when the server
+// stays unreachable the error wraps ErrUnreachable. A POST is only sent
+// again when the server cannot have acted on it, so a push that went
+// through is never applied twice.
+func request(c Config, method, path string, body any, header http.Header) (*http.Response, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	client := c.HTTP
+	if client == nil {
+		client = httpClient
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest(method, c.endpoint(path), bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		switch {
+		case c.Token != "":
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		case c.Username != "":
+			req.SetBasicAuth(c.Username, c.Password)
+		}
+
+		resp, err := client.Do(req)
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			return nil, fmt.Errorf("%w; give the server's certificate authority with --ca-cert, or skip the check with --insecure", err)
+		}
+		retry, wait := transient(method, resp, err)
+		if !retry {
+			return resp, err
+		}
+		if attempt == retryAttempts {
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrUnreachable, err)
+			}
+			return resp, nil
+		}
+
+		reason := "no answer"
+		if resp != nil {
+			reason = resp.Status
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if wait == 0 {
+			wait = backoff(attempt)
+		}
+		c.notify(fmt.Sprintf("%s from %s; retrying in %s", reason, c.ServerURL, wait.Round(100*time.Millisecond)))
+		time.Sleep(wait)
+	}
+}
+
+// transient reports whether a request with method that ended with resp or
+// err may work if sent again, and how long the server asked to wait first.
+// Requests that are not idempotent are only retried when they never
+// reached the server: the connection could not be made, or the server
+// turned them away with 429 or 503 without acting on them. A dropped
+// connection, a timeout or a 502 or 504 from a proxy may come after the
+// server applied the request.
+func transient(method string, resp *http.Response, err error) (bool, time.Duration) {
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "remote error" {
+			// A TLS alert: the server refused the handshake and will again
+			return false, 0
+		}
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) || errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true, 0
+		}
+		if !idempotent(method) {
+			return false, 0
+		}
+		return errors.As(err, &opErr) || os.IsTimeout(err) ||
+			errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF), 0
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		if !idempotent(method) {
+			return false, 0
+		}
+		fallthrough
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		wait := time.Duration(0)
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			wait = min(time.Duration(seconds)*time.Second, retryMax)
+		}
+		return true, wait
+	}
+	return false, 0
+}
+
+// idempotent reports whether sending a request with method twice has the
+// same effect as sending it once
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// backoff returns how long to wait before retry number attempt: an
+// exponentially growing delay, with jitter so that clients that failed
+// together do not all come back at once
+func backoff(attempt int) time.Duration {
+	d := min(retryBase<<(attempt-1), retryMax)
+	return d/2 + rand.N(d/2)
+}
+
+// StatusError reports an unexpected response from a todo server
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s (Status: %d)", e.Message, e.Code)
+}
+
+// statusError reads the error in resp's body: the message of a JSON
+// {"error": ...} as 'todo serve' answers, or else the body as it is
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	message := strings.TrimSpace(string(body))
+	var served struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &served) == nil && served.Error != "" {
+		message = served.Error
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+	return &StatusError{Code: resp.StatusCode, Message: message}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestTransient(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	alert := &net.OpError{Op: "remote error", Err: errors.New("tls: bad certificate")}
+	status := func(code int) *http.Response { return &http.Response{StatusCode: code, Header: http.Header{}} }
+
+	tests := []struct {
+		name   string
+		method string
+		resp   *http.Response
+		err    error
+		want   bool
+	}{
+		{"GET refused", "GET", nil, refused, true},
+		{"GET reset", "GET", nil, reset, true},
+		{"GET dropped", "GET", nil, io.ErrUnexpectedEOF, true},
+		{"GET TLS alert", "GET", nil, alert, false},
+		{"GET 502", "GET", status(http.StatusBadGateway), nil, true},
+		{"GET 404", "GET", status(http.StatusNotFound), nil, false},
+		{"POST refused", "POST", nil, refused, true},
+		{"POST unknown host", "POST", nil, &net.DNSError{Err: "no such host", IsNotFound: true}, true},
+		{"POST reset", "POST", nil, reset, false},
+		{"POST dropped", "POST", nil, io.EOF, false},
+		{"POST 429", "POST", status(http.StatusTooManyRequests), nil, true},
+		{"POST 503", "POST", status(http.StatusServiceUnavailable), nil, true},
+		{"POST 502", "POST", status(http.StatusBadGateway), nil, false},
+		{"POST 504", "POST", status(http.StatusGatewayTimeout), nil, false},
+		{"PUT reset", "PUT", nil, reset, true},
+	}
+	for _, tt := range tests {
+		if got, _ := transient(tt.method, tt.resp, tt.err); got != tt.want {
+			t.Errorf("%s: transient = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestPostNotResent checks that a push whose connection drops after the
+// server read it is not sent a second time
+func TestPostNotResent(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	requests := make(chan struct{}, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Read(make([]byte, 4096))
+			requests <- struct{}{}
+			conn.Close()
+		}
+	}()
+
+	c := Config{ServerURL: "http://" + listener.Addr().String()}
+	if _, _, err := PushChanges(c, "", nil); err == nil {
+		t.Fatal("push succeeded on a dropped connection")
+	}
+	if n := len(requests); n != 1 {
+		t.Errorf("the push was sent %d times, want 1", n)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"error": "invalid username or password"}`: "invalid username or password",
+		"Bad Gateway from the proxy\n":              "Bad Gateway from the proxy",
+		"":                                          "Unauthorized",
+	} {
+		resp := &http.Response{StatusCode: http.StatusUnauthorized, Body: io.NopCloser(strings.NewReader(body))}
+		var status *StatusError
+		if err := statusError(resp); !errors.As(err, &status) || status.Message != want || status.Code != 401 {
+			t.Errorf("statusError for %q = %v, want %q", body, err, want)
+		}
+	}
+}
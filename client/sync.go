@@ -0,0 +1,217 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"todo-bubbletea/merge"
+	"todo-bubbletea/outbox"
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+// syncAttempts is how many times Sync starts over when the server changes
+// while it runs
+const syncAttempts = 3
+
+// Sync merges the todos in st with the server's against the base saved at
+// basePath after the last sync, then writes the result to both sides and
+// saves it as the new base. Conflicting edits go to resolve; with a nil
+// resolve Sync returns an error wrapping merge.ErrConflict, and a result
+// listing the conflicts, and writes nothing. Servers with a change feed
+// only send and receive the todos changed since the last sync.
+//
+// The server is written first, so when it cannot be reached the local
+// todos and the base stay as they were and st's outbox still holds every
+// change made here, to be replayed by the next sync that gets through.
+// Local writes during the sync make it fail with store.ErrChanged.
+func Sync(c Config, st store.Store, basePath string, resolve merge.Resolver) (*merge.Result, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := syncOnce(c, st, basePath, resolve)
+		if !errors.Is(err, ErrServerChanged) || attempt == syncAttempts {
+			return result, err
+		}
+		c.notify("The server changed during sync; trying again")
+	}
+}
+
+// syncOnce is one attempt at Sync
+func syncOnce(c Config, st store.Store, basePath string, resolve merge.Resolver) (*merge.Result, error) {
+	base, err := merge.LoadBase(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading last synced copy: %w", err)
+	}
+	// Read before the todos, so that nothing queued after them is
+	// acknowledged with them
+	box := outbox.Of(st)
+	queued, mark, err := box.Pending(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading outbox: %w", err)
+	}
+	local, err := store.SnapshotAll(st)
+	if err != nil {
+		return nil, fmt.Errorf("loading todos: %w", err)
+	}
+	remote, delta, err := fetchRemote(c, base)
+	if err != nil {
+		return nil, fmt.Errorf("loading from network: %w", err)
+	}
+
+	var baseList *todo.TodoList
+	if base != nil {
+		baseList = base.List
+	}
+	result, err := merge.Merge(baseList, local, remote.List, resolve)
+	if err != nil {
+		return result, err
+	}
+
+	synced := &merge.Base{List: result.List, Cursor: remote.Cursor, ETag: remote.ETag}
+	if delta {
+		if changes := replay(queued, remote.List, result.List); len(changes) > 0 {
+			synced.Cursor, synced.ETag, err = PushChanges(c, remote.Cursor, changes)
+		}
+	} else {
+		synced.ETag, err = Replace(c, result.List, IfMatch(remote.ETag))
+	}
+	if err != nil {
+		if errors.Is(err, ErrServerChanged) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("saving to network: %w", err)
+	}
+	// What the server sent is recorded as coming from it, so it is not
+	// sent back, while other remotes still get it
+	if err := store.CompareAndReplace(outbox.WithSource(st, basePath), local, result.List); err != nil {
+		if errors.Is(err, store.ErrChanged) {
+			return nil, fmt.Errorf("local %w during sync", err)
+		}
+		return nil, fmt.Errorf("saving merged todos: %w", err)
+	}
+	synced.SyncedAt = time.Now()
+	if err := merge.SaveBase(basePath, synced); err != nil {
+		return nil, fmt.Errorf("recording sync: %w", err)
+	}
+	if err := box.Ack(basePath, mark); err != nil {
+		return nil, fmt.Errorf("recording sync in outbox: %w", err)
+	}
+	return result, nil
+}
+
+// replay returns what to push to a server with a change feed: the queued
+// changes in the order they were made, each as it was then, followed by
+// the merged state of every todo the queue touched or the server's copy
+// differs from, so that the server ends up with the merged list
+func replay(queued []outbox.Entry, remote, merged *todo.TodoList) []todo.Todo {
+	final := make(map[string]todo.Todo, len(merged.Todos))
+	for _, t := range merged.Todos {
+		final[t.UUID] = t
+	}
+
+	changes := []todo.Todo{}
+	last := map[string]todo.Todo{}
+	for _, e := range queued {
+		if _, ok := final[e.Todo.UUID]; !ok {
+			// Purged since, or never given a UUID
+			continue
+		}
+		changes = append(changes, e.Todo)
+		last[e.Todo.UUID] = e.Todo
+	}
+
+	send := map[string]bool{}
+	for _, t := range merge.Changes(remote, merged) {
+		send[t.UUID] = true
+	}
+	for uuid, t := range last {
+		f := final[uuid]
+		if len(merge.ChangedFields(t, f)) > 0 || t.Deleted() != f.Deleted() {
+			send[uuid] = true
+		}
+	}
+	for _, t := range merged.Todos {
+		if send[t.UUID] {
+			changes = append(changes, t)
+		}
+	}
+	return changes
+}
+
+// fetchRemote returns the server's list with its cursor and ETag. From a
+// server with a change feed it fetches only the todos changed since the
+// base's cursor and lays them over the base, falling back to every todo
+// when there is no base or the cursor has expired; delta reports that the
+// feed was used.
+func fetchRemote(c Config, base *merge.Base) (remote *merge.Base, delta bool, err error) {
+	var cursor string
+	if base != nil {
+		cursor = base.Cursor
+	}
+	feed, etag, err := FetchChanges(c, cursor)
+	if errors.Is(err, ErrCursorExpired) {
+		cursor = ""
+		feed, etag, err = FetchChanges(c, cursor)
+	}
+	if errors.Is(err, ErrNoChangeFeed) {
+		list, etag, err := Fetch(c)
+		// A server that has no todos yet may answer 404
+		var status *StatusError
+		if errors.As(err, &status) && status.Code == http.StatusNotFound {
+			list, err = todo.NewTodoList(), nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return &merge.Base{List: list, ETag: etag}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	remote = &merge.Base{Cursor: feed.Cursor, ETag: etag}
+	if cursor == "" {
+		remote.List = merge.Apply(nil, feed.Changes)
+	} else {
+		remote.List = merge.Apply(base.List, feed.Changes)
+	}
+	return remote, true, nil
+}
+
+// Status returns the base saved at basePath after the last sync, nil if
+// there has been none, and the changes the next sync sends: those queued
+// in st's outbox in the order they were made, then any change since the
+// base the outbox has no record of, such as ones made before it existed.
+// It does not contact the server.
+func Status(st store.Store, basePath string) (*merge.Base, []merge.Pending, error) {
+	base, err := merge.LoadBase(basePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading last synced copy: %w", err)
+	}
+	queued, _, err := outbox.Of(st).Pending(basePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading outbox: %w", err)
+	}
+	local, err := store.SnapshotAll(st)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading todos: %w", err)
+	}
+
+	var baseList *todo.TodoList
+	if base != nil {
+		baseList = base.List
+	}
+	var pending []merge.Pending
+	queuedUUIDs := map[string]bool{}
+	for _, e := range queued {
+		pending = append(pending, e.Pending())
+		queuedUUIDs[e.Todo.UUID] = true
+	}
+	for _, p := range merge.Diff(baseList, local) {
+		if !queuedUUIDs[p.Todo.UUID] {
+			pending = append(pending, p)
+		}
+	}
+	return base, pending, nil
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"todo-bubbletea/merge"
+	"todo-bubbletea/outbox"
+	"todo-bubbletea/server"
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testServer is a todo server that records the pushes it is sent and can
+// be made to fail every request
+type testServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	down   bool
+	pushes [][]todo.Todo
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	dir := t.TempDir()
+	s := server.New(func(list string) (store.Store, error) {
+		return store.Open(store.BackendJSON, filepath.Join(dir, list+".json"))
+	}, "default", nil, 0)
+
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts.mu.Lock()
+		down := ts.down
+		ts.mu.Unlock()
+		if down {
+			http.Error(w, "down for maintenance", http.StatusInternalServerError)
+			return
+		}
+		if r.Method == "POST" && r.URL.Path == server.ChangesPath {
+			var push server.Changes
+			data, _ := io.ReadAll(r.Body)
+			json.Unmarshal(data, &push)
+			ts.mu.Lock()
+			ts.pushes = append(ts.pushes, push.Changes)
+			ts.mu.Unlock()
+			r.Body = io.NopCloser(bytes.NewReader(data))
+		}
+		s.ServeHTTP(w, r)
+	}))
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	return ts
+}
+
+func (ts *testServer) setDown(down bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.down = down
+}
+
+func (ts *testServer) pushCount() int {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return len(ts.pushes)
+}
+
+func (ts *testServer) lastPush() []todo.Todo {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if len(ts.pushes) == 0 {
+		return nil
+	}
+	return ts.pushes[len(ts.pushes)-1]
+}
+
+// localStore opens a JSON store recording into its own outbox, and
+// returns it with the path of its sync base
+func localStore(t *testing.T) (store.Store, string) {
+	t.Helper()
+	dir := t.TempDir()
+	st, err := store.Open(store.BackendJSON, filepath.Join(dir, "todos.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return outbox.Wrap(st, outbox.Open(filepath.Join(dir, "outbox.json"))), filepath.Join(dir, "base.json")
+}
+
+func TestSyncBetweenMachines(t *testing.T) {
+	ts := newTestServer(t)
+	c := Config{ServerURL: ts.URL}
+	laptop, laptopBase := localStore(t)
+	desktop, desktopBase := localStore(t)
+
+	if err := laptop.Put(todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Milk", Priority: "low"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sync(c, laptop, laptopBase, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := desktop.Put(todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Bread", Priority: "low"}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Sync(c, desktop, desktopBase, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Renumbered[1] != 2 {
+		t.Errorf("Renumbered = %v, want the laptop's #1 to become #2", result.Renumbered)
+	}
+	if _, err := Sync(c, laptop, laptopBase, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, st := range map[string]store.Store{"laptop": laptop, "desktop": desktop} {
+		todos, _ := st.List()
+		if len(todos) != 2 {
+			t.Errorf("%s has %d todos, want 2", name, len(todos))
+		}
+	}
+	if _, pending, _ := Status(laptop, laptopBase); len(pending) != 0 {
+		t.Errorf("laptop still has %d pending changes", len(pending))
+	}
+
+	// Only what changed since the last sync is pushed
+	before := ts.pushCount()
+	if _, err := Sync(c, laptop, laptopBase, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ts.pushCount() != before {
+		t.Errorf("a sync with nothing to send pushed %+v", ts.lastPush())
+	}
+	todos, _ := laptop.List()
+	todos[0].Completed = true
+	if err := laptop.Put(todos[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sync(c, laptop, laptopBase, nil); err != nil {
+		t.Fatal(err)
+	}
+	// The queued edit goes first, then the todo as merged
+	push := ts.lastPush()
+	if len(push) == 0 || !push[len(push)-1].Completed {
+		t.Fatalf("pushed %+v, want the completed todo", push)
+	}
+	for _, p := range push {
+		if p.UUID != todos[0].UUID {
+			t.Errorf("pushed %s %q, want only the completed todo", p.UUID, p.Title)
+		}
+	}
+}
+
+func TestSyncReplaysOfflineChanges(t *testing.T) {
+	ts := newTestServer(t)
+	c := Config{ServerURL: ts.URL}
+	st, basePath := localStore(t)
+
+	milk := todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Milk", Priority: "low"}
+	if err := st.Put(milk); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sync(c, st, basePath, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Offline: edit the todo, then delete it
+	ts.setDown(true)
+	milk.Title = "Oat milk"
+	if err := st.Put(milk); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sync(c, st, basePath, nil); err == nil {
+		t.Fatal("sync with a failing server succeeded")
+	}
+	_, pending, err := Status(st, basePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].Change != merge.Edited || pending[1].Change != merge.Deleted {
+		t.Fatalf("pending = %+v, want the edit then the deletion", pending)
+	}
+
+	ts.setDown(false)
+	if _, err := Sync(c, st, basePath, nil); err != nil {
+		t.Fatal(err)
+	}
+	push := ts.lastPush()
+	if len(push) < 2 || push[0].Title != "Oat milk" || push[0].Deleted() || !push[1].Deleted() {
+		t.Errorf("pushed %+v, want the edit and then the tombstone", push)
+	}
+	if _, pending, _ := Status(st, basePath); len(pending) != 0 {
+		t.Errorf("%d changes still pending after the sync", len(pending))
+	}
+
+	remote, _, err := Fetch(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remote.Todos) != 1 || !remote.Todos[0].Deleted() || remote.Todos[0].Title != "Oat milk" {
+		t.Errorf("server holds %+v, want the edited todo's tombstone", remote.Todos)
+	}
+
+	// Nothing the server sent is pushed back to it
+	before := ts.pushCount()
+	if _, err := Sync(c, st, basePath, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ts.pushCount() != before {
+		t.Errorf("a sync with nothing to send pushed %+v", ts.lastPush())
+	}
+}
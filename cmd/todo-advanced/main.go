@@ -0,0 +1,1289 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"todo-bubbletea/config"
+	"todo-bubbletea/outbox"
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+// Styles
+var (
+	titleStyle          = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FAFAFA")).Background(lipgloss.Color("#7D56F4")).Padding(0, 1)
+	itemStyle           = lipgloss.NewStyle().PaddingLeft(2)
+	selectedItemStyle   = lipgloss.NewStyle().PaddingLeft(1).Foreground(lipgloss.Color("#7D56F4"))
+	completedStyle      = lipgloss.NewStyle().Strikethrough(true).Foreground(lipgloss.Color("#757575"))
+	pendingStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
+	highPriorityStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6B6B"))
+	mediumPriorityStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD93D"))
+	lowPriorityStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#6BCF7F"))
+	helpStyle           = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	errorStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6B6B"))
+	successStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
+	infoStyle           = lipgloss.NewStyle().Foreground(lipgloss.Color("#4A90E2"))
+	warningStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("#F5A623"))
+)
+
+// List item implementation
+type todoItem struct {
+	todo todo.Todo
+}
+
+func (i todoItem) Title() string {
+	title := i.todo.Title
+	if i.todo.Completed {
+		title = completedStyle.Render(title)
+	} else {
+		title = pendingStyle.Render(title)
+	}
+	return title
+}
+
+func (i todoItem) Description() string {
+	desc := i.todo.Description
+	if desc == "" {
+		desc = "No description"
+	}
+
+	// Add priority indicator
+	priority := ""
+	switch i.todo.Priority {
+	case "high":
+		priority = highPriorityStyle.Render("🔴 HIGH")
+	case "medium":
+		priority = mediumPriorityStyle.Render("🟡 MED")
+	case "low":
+		priority = lowPriorityStyle.Render("🟢 LOW")
+	default:
+		priority = lowPriorityStyle.Render("🟢 LOW")
+	}
+
+	status := "⏳ Pending"
+	if i.todo.Completed {
+		status = "✅ Completed"
+	}
+
+	// Add category
+	category := ""
+	if i.todo.Category != "" {
+		category = fmt.Sprintf(" | 📁 %s", i.todo.Category)
+	}
+
+	// Add due date
+	dueDate := ""
+	if i.todo.DueDate != nil {
+		now := time.Now()
+		due := *i.todo.DueDate
+		if due.Before(now) && !i.todo.Completed {
+			dueDate = warningStyle.Render(fmt.Sprintf(" | ⚠️ Overdue (%s)", due.Format("Jan 2")))
+		} else if due.Before(now.Add(24*time.Hour)) && !i.todo.Completed {
+			dueDate = warningStyle.Render(fmt.Sprintf(" | ⏰ Due soon (%s)", due.Format("Jan 2")))
+		} else {
+			dueDate = infoStyle.Render(fmt.Sprintf(" | 📅 Due %s", due.Format("Jan 2")))
+		}
+	}
+
+	return fmt.Sprintf("%s | %s%s%s | %s", priority, status, category, dueDate, desc)
+}
+
+func (i todoItem) FilterValue() string {
+	return i.todo.Title + " " + i.todo.Description + " " + i.todo.Category
+}
+
+// Main model
+type model struct {
+	cfg           *config.Config
+	store         store.Store
+	storeBackend  string
+	storePath     string
+	listName      string // named list shown, see config.Lists
+	todos         []todo.Todo
+	saved         *todo.TodoList // the store, tombstones included, as last read here
+	list          list.Model
+	textInput     textinput.Model
+	descInput     textinput.Model
+	categoryInput textinput.Model
+	listInput     textinput.Model
+	filterInput   textinput.Model
+	state         string // "list", "add", "edit", "add_desc", "add_category", "add_priority", "add_due", "load_error", "pick_backup", "pick_list", "new_list", "filter"
+	editingID     int
+	nextID        int
+	message       string
+	messageType   string
+	currentField  string
+	priority      string
+	dueDate       string
+	loadErr       error    // why todos.json could not be opened
+	backups       []string // backup files offered in "pick_backup"
+	backupIndex   int
+	dirty         bool     // in-memory todos differ from what is on disk
+	quitPending   bool     // quit was requested with unsaved changes
+	sorted        bool     // show todos in priority order rather than by ID
+	lists         []string // list names offered in "pick_list"
+	listIndex     int
+	movingID      int        // todo being moved to another list, 0 when switching lists
+	query         todo.Query // filter applied to the list, see todo.ParseQuery
+	queryText     string
+	sync          autoSync // background sync with a todo server, see sync.go
+}
+
+// Messages
+type todoAddedMsg struct{}
+type todoUpdatedMsg struct{}
+type todoDeletedMsg struct{}
+type messageMsg struct {
+	text    string
+	msgType string
+}
+
+// Initial model
+func initialModel(cfg *config.Config, sync autoSync) model {
+	st, path, err := cfg.OpenStore()
+	todos, nextID, loadErr := []todo.Todo{}, 1, err
+	var saved *todo.TodoList
+	if err == nil {
+		todos, nextID, saved, loadErr = loadTodos(st)
+	}
+
+	items := make([]list.Item, len(todos))
+	for i, todo := range todos {
+		items[i] = todoItem{todo: todo}
+	}
+
+	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
+	l.Title = listTitle(cfg.CurrentList())
+	l.SetShowStatusBar(true)
+	// "/" opens the query filter bar instead of the list's own fuzzy filter
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(true)
+	l.Styles.Title = titleStyle
+	l.Styles.PaginationStyle = helpStyle
+	l.Styles.HelpStyle = helpStyle
+
+	ti := textinput.New()
+	ti.Placeholder = "Enter todo title..."
+	ti.Focus()
+	ti.CharLimit = 100
+	ti.Width = 50
+
+	di := textinput.New()
+	di.Placeholder = "Enter description (optional)..."
+	di.CharLimit = 200
+	di.Width = 50
+
+	ci := textinput.New()
+	ci.Placeholder = "Enter category (optional)..."
+	ci.CharLimit = 50
+	ci.Width = 50
+
+	li := textinput.New()
+	li.Placeholder = "Enter list name..."
+	li.CharLimit = 50
+	li.Width = 50
+
+	fi := textinput.New()
+	fi.Prompt = "Filter: "
+	fi.Placeholder = `status:pending priority:high due<2026-11-01 "text"`
+	fi.CharLimit = 200
+	fi.Width = 60
+
+	m := model{
+		cfg:           cfg,
+		store:         st,
+		storeBackend:  cfg.Store.Backend,
+		storePath:     path,
+		listName:      cfg.CurrentList(),
+		todos:         todos,
+		saved:         saved,
+		list:          l,
+		textInput:     ti,
+		descInput:     di,
+		categoryInput: ci,
+		listInput:     li,
+		filterInput:   fi,
+		state:         "list",
+		nextID:        nextID,
+		priority:      "low",
+		sync:          sync,
+	}
+	m = m.refreshSyncStatus()
+
+	if loadErr != nil {
+		m.state = "load_error"
+		m.loadErr = loadErr
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		if legacy := cfg.LegacyDataFile(cwd); legacy != "" {
+			m = m.setMessage(fmt.Sprintf("Found %s from an older version; run 'todo move-data' to move it to %s", legacy, path), "info")
+		}
+	}
+
+	return m
+}
+
+// Commands
+func (m model) Init() tea.Cmd {
+	if m.sync.enabled() {
+		// Pick up the server's changes right away
+		return tea.Batch(textinput.Blink, func() tea.Msg { return syncTickMsg{gen: m.sync.gen} })
+	}
+	return textinput.Blink
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := titleStyle.GetFrameSize()
+		m.list.SetSize(msg.Width, msg.Height-h-v-2)
+
+	case tea.KeyMsg:
+		switch m.state {
+		case "list":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("a"))):
+				m.state = "add"
+				m.textInput.Reset()
+				m.textInput.Placeholder = "Enter todo title..."
+				m.textInput.Focus()
+				return m, textinput.Blink
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("e"))):
+				if len(m.list.Items()) > 0 {
+					selectedItem := m.list.SelectedItem().(todoItem)
+					m.state = "edit"
+					m.editingID = selectedItem.todo.ID
+					m.textInput.SetValue(selectedItem.todo.Title)
+					m.textInput.Focus()
+					return m, textinput.Blink
+				}
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("d"))):
+				if len(m.list.Items()) > 0 {
+					selectedItem := m.list.SelectedItem().(todoItem)
+					m = m.deleteTodo(selectedItem.todo.ID)
+					return m, nil
+				}
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys(" "))):
+				if len(m.list.Items()) > 0 {
+					selectedItem := m.list.SelectedItem().(todoItem)
+					m = m.toggleTodo(selectedItem.todo.ID)
+					return m, nil
+				}
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("s"))):
+				m = m.sortTodos()
+				return m, nil
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("c"))):
+				m = m.showCategories()
+				return m, nil
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("S"))):
+				if !m.sync.enabled() {
+					m = m.setMessage("No remote to sync with; add one with 'todo remote add <name> <url> --default'", "info")
+					return m, nil
+				}
+				return m.startSync()
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("w"))):
+				m = m.pickList(0)
+				return m, nil
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("/"))):
+				m.state = "filter"
+				m.filterInput.SetValue(m.queryText)
+				m.filterInput.CursorEnd()
+				m.filterInput.Focus()
+				return m, textinput.Blink
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				if m.queryText != "" {
+					m = m.applyFilter("")
+					return m, nil
+				}
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("m"))):
+				if len(m.list.Items()) > 0 {
+					selectedItem := m.list.SelectedItem().(todoItem)
+					m = m.pickList(selectedItem.todo.ID)
+					return m, nil
+				}
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("q"))):
+				if m.dirty && !m.quitPending {
+					// Retry the save once before letting unsaved changes go
+					m = m.save()
+					if m.dirty {
+						m.quitPending = true
+						m = m.setMessage(m.message+" (press q again to quit without saving)", "error")
+						return m, nil
+					}
+				}
+				return m, tea.Quit
+			}
+
+		case "load_error":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"))):
+				return m, tea.Quit
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("r"))):
+				m = m.retryLoad()
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("b"))):
+				if errors.Is(m.loadErr, todo.ErrLocked) {
+					return m, nil
+				}
+				backups, err := todo.Backups(m.storePath)
+				if err != nil {
+					m = m.setMessage(fmt.Sprintf("Could not list backups: %v", err), "error")
+				} else if len(backups) == 0 {
+					m = m.setMessage("No backups found", "info")
+				} else {
+					m.backups = backups
+					m.backupIndex = 0
+					m.state = "pick_backup"
+					m = m.setMessage("", "")
+				}
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("n"))):
+				if errors.Is(m.loadErr, todo.ErrLocked) {
+					return m, nil
+				}
+				m = m.startFresh()
+			}
+			return m, nil
+
+		case "pick_backup":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("up", "k"))):
+				if m.backupIndex > 0 {
+					m.backupIndex--
+				}
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("down", "j"))):
+				if m.backupIndex < len(m.backups)-1 {
+					m.backupIndex++
+				}
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+				m = m.openBackup(m.backups[m.backupIndex])
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				m.state = "load_error"
+			}
+			return m, nil
+
+		case "pick_list":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("up", "k"))):
+				if m.listIndex > 0 {
+					m.listIndex--
+				}
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("down", "j"))):
+				// The last row is "new list"
+				if m.listIndex < len(m.lists) {
+					m.listIndex++
+				}
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+				if m.listIndex == len(m.lists) {
+					m.state = "new_list"
+					m.listInput.Reset()
+					m.listInput.Focus()
+					return m, textinput.Blink
+				}
+				m = m.chooseList(m.lists[m.listIndex])
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc", "q"))):
+				m.state = "list"
+			}
+			return m, nil
+
+		case "filter":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+				m.state = "list"
+				m.filterInput.Blur()
+				return m, nil
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				m.state = "list"
+				m.filterInput.Blur()
+				m = m.applyFilter("")
+				return m, nil
+			}
+
+		case "new_list":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+				name := strings.TrimSpace(m.listInput.Value())
+				if err := config.ValidateListName(name); err != nil {
+					m = m.setMessage(err.Error(), "error")
+					return m, nil
+				}
+				m = m.chooseList(name)
+				return m, nil
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				m.state = "pick_list"
+				return m, nil
+			}
+
+		case "add":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+				title := strings.TrimSpace(m.textInput.Value())
+				if title == "" {
+					m = m.setMessage("Title cannot be empty", "error")
+					return m, nil
+				}
+				m.currentField = "title"
+				m.state = "add_desc"
+				m.descInput.Reset()
+				m.descInput.Focus()
+				return m, textinput.Blink
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				m.state = "list"
+				m.textInput.Reset()
+				return m, nil
+			}
+
+		case "add_desc":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+				m.state = "add_category"
+				m.categoryInput.Reset()
+				m.categoryInput.Focus()
+				return m, textinput.Blink
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				m.state = "add"
+				return m, nil
+			}
+
+		case "add_category":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+				m.state = "add_priority"
+				return m, nil
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				m.state = "add_desc"
+				return m, nil
+			}
+
+		case "add_priority":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("1"))):
+				m.priority = "low"
+				m.state = "add_due"
+				return m, nil
+			case key.Matches(msg, key.NewBinding(key.WithKeys("2"))):
+				m.priority = "medium"
+				m.state = "add_due"
+				return m, nil
+			case key.Matches(msg, key.NewBinding(key.WithKeys("3"))):
+				m.priority = "high"
+				m.state = "add_due"
+				return m, nil
+			case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+				m = m.finishAddTodo()
+				return m, nil
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				m.state = "add_category"
+				return m, nil
+			}
+
+		case "add_due":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+				m = m.finishAddTodo()
+				return m, nil
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				m.state = "add_priority"
+				return m, nil
+			}
+
+		case "edit":
+			switch {
+			case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+				title := strings.TrimSpace(m.textInput.Value())
+				if title == "" {
+					m = m.setMessage("Title cannot be empty", "error")
+					return m, nil
+				}
+				m = m.updateTodo(m.editingID, title)
+				m.state = "list"
+				m.textInput.Reset()
+				return m, nil
+
+			case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				m.state = "list"
+				m.textInput.Reset()
+				return m, nil
+			}
+		}
+
+	case messageMsg:
+		m.message = msg.text
+		m.messageType = msg.msgType
+		return m, nil
+
+	case syncTickMsg:
+		if msg.gen != m.sync.gen {
+			return m, nil
+		}
+		return m.startSync()
+
+	case syncDoneMsg:
+		return m.finishSync(msg)
+	}
+
+	// Update the appropriate component
+	if m.state == "add" || m.state == "edit" {
+		m.textInput, cmd = m.textInput.Update(msg)
+	} else if m.state == "add_desc" {
+		m.descInput, cmd = m.descInput.Update(msg)
+	} else if m.state == "add_category" {
+		m.categoryInput, cmd = m.categoryInput.Update(msg)
+	} else if m.state == "new_list" {
+		m.listInput, cmd = m.listInput.Update(msg)
+	} else if m.state == "filter" {
+		m.filterInput, cmd = m.filterInput.Update(msg)
+		if _, ok := msg.(tea.KeyMsg); ok {
+			m = m.applyFilter(m.filterInput.Value())
+		}
+	} else {
+		m.list, cmd = m.list.Update(msg)
+	}
+
+	return m, cmd
+}
+
+func (m model) View() string {
+	switch m.state {
+	case "add":
+		return fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			titleStyle.Render("➕ Add New Todo"),
+			m.textInput.View(),
+			helpStyle.Render("Press Enter to continue, Esc to cancel"),
+		)
+
+	case "add_desc":
+		return fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			titleStyle.Render("📝 Add Description"),
+			m.descInput.View(),
+			helpStyle.Render("Press Enter to continue, Esc to go back"),
+		)
+
+	case "add_category":
+		return fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			titleStyle.Render("📁 Add Category"),
+			m.categoryInput.View(),
+			helpStyle.Render("Press Enter to continue, Esc to go back"),
+		)
+
+	case "add_priority":
+		return fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			titleStyle.Render("🎯 Select Priority"),
+			"1. Low (Green)\n2. Medium (Yellow)\n3. High (Red)\n\nPress Enter to skip",
+			helpStyle.Render("Press 1-3 to select priority, Enter to skip, Esc to go back"),
+		)
+
+	case "add_due":
+		return fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			titleStyle.Render("📅 Set Due Date"),
+			"Enter due date (YYYY-MM-DD) or press Enter to skip",
+			helpStyle.Render("Press Enter to skip, Esc to go back"),
+		)
+
+	case "edit":
+		return fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			titleStyle.Render("✏️ Edit Todo"),
+			m.textInput.View(),
+			helpStyle.Render("Press Enter to save, Esc to cancel"),
+		)
+
+	case "load_error":
+		options := "r: retry  b: open a backup  n: start fresh  q: quit"
+		if errors.Is(m.loadErr, todo.ErrLocked) {
+			options = "r: retry  q: quit"
+		}
+		view := fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			titleStyle.Render("⚠️ Could Not Load Todos"),
+			errorStyle.Render(fmt.Sprintf("%s: %v", m.storePath, m.loadErr)),
+			helpStyle.Render(options),
+		)
+		if m.message != "" {
+			view = fmt.Sprintf("%s\n\n%s", view, m.messageView())
+		}
+		return view
+
+	case "pick_backup":
+		var b strings.Builder
+		for i, name := range m.backups {
+			line := itemStyle.Render(name)
+			if i == m.backupIndex {
+				line = selectedItemStyle.Render("> " + name)
+			}
+			b.WriteString(line + "\n")
+		}
+		view := fmt.Sprintf(
+			"%s\n\n%s\n%s",
+			titleStyle.Render("🗂 Open a Backup"),
+			b.String(),
+			helpStyle.Render("↑/↓ to choose, Enter to open, Esc to go back"),
+		)
+		if m.message != "" {
+			view = fmt.Sprintf("%s\n\n%s", view, m.messageView())
+		}
+		return view
+
+	case "pick_list":
+		title := "🗂 Switch List"
+		if m.movingID != 0 {
+			title = fmt.Sprintf("📦 Move Todo #%d To", m.movingID)
+		}
+		var b strings.Builder
+		for i, name := range append(m.lists, "+ new list") {
+			if name == m.listName {
+				name += " (current)"
+			}
+			line := itemStyle.Render(name)
+			if i == m.listIndex {
+				line = selectedItemStyle.Render("> " + name)
+			}
+			b.WriteString(line + "\n")
+		}
+		view := fmt.Sprintf(
+			"%s\n\n%s\n%s",
+			titleStyle.Render(title),
+			b.String(),
+			helpStyle.Render("↑/↓ to choose, Enter to select, Esc to go back"),
+		)
+		if m.message != "" {
+			view = fmt.Sprintf("%s\n\n%s", view, m.messageView())
+		}
+		return view
+
+	case "new_list":
+		view := fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			titleStyle.Render("➕ New List"),
+			m.listInput.View(),
+			helpStyle.Render("Press Enter to create, Esc to go back"),
+		)
+		if m.message != "" {
+			view = fmt.Sprintf("%s\n\n%s", view, m.messageView())
+		}
+		return view
+
+	case "filter":
+		view := fmt.Sprintf("%s\n\n%s", m.list.View(), m.filterInput.View())
+		if m.message != "" {
+			view = fmt.Sprintf("%s\n%s", view, m.messageView())
+		}
+		return fmt.Sprintf("%s\n\n%s", view, helpStyle.Render("Press Enter to keep the filter, Esc to clear it"))
+
+	default:
+		view := m.list.View()
+
+		if m.queryText != "" {
+			view = fmt.Sprintf("%s\n%s", view, infoStyle.Render("Filter: "+m.queryText+" (/ to change, esc to clear)"))
+		}
+
+		// Add message if any
+		if m.message != "" {
+			view = fmt.Sprintf("%s\n\n%s", view, m.messageView())
+		}
+
+		// Unsaved changes stay visible until a save succeeds
+		if m.dirty {
+			view = fmt.Sprintf("%s\n%s", view, warningStyle.Render("● Unsaved changes"))
+		}
+		if status := m.syncView(); status != "" {
+			view = fmt.Sprintf("%s\n%s", view, status)
+		}
+
+		// Add help text
+		keys := "Press 'a' to add, 'e' to edit, 'd' to delete, 'space' to toggle, 's' to sort, 'c' for categories, '/' to filter, 'w' to switch list, 'm' to move"
+		if m.sync.enabled() {
+			keys += ", 'S' to sync now"
+		}
+		help := helpStyle.Render(keys + ", 'q' to quit")
+		view = fmt.Sprintf("%s\n\n%s", view, help)
+
+		return view
+	}
+}
+
+// Todo operations
+func (m model) finishAddTodo() model {
+	title := strings.TrimSpace(m.textInput.Value())
+	description := strings.TrimSpace(m.descInput.Value())
+	category := strings.TrimSpace(m.categoryInput.Value())
+
+	todo := todo.Todo{
+		ID:          m.nextID,
+		UUID:        todo.NewUUID(),
+		Title:       title,
+		Description: description,
+		Completed:   false,
+		CreatedAt:   time.Now(),
+		Priority:    m.priority,
+		Category:    category,
+	}
+
+	// Parse due date if provided
+	if m.dueDate != "" {
+		if due, err := time.Parse("2006-01-02", m.dueDate); err == nil {
+			todo.DueDate = &due
+		}
+	}
+
+	m.todos = append(m.todos, todo)
+	m.nextID++
+	m = m.updateList()
+	m = m.setMessage(fmt.Sprintf("Added: %s", title), "success")
+	m = m.commit(func(tx store.Tx) error {
+		// Take the ID from the store in case another process added
+		// todos since we loaded
+		id, err := tx.NextID()
+		if err != nil {
+			return err
+		}
+		todo.ID = id
+		return tx.Put(todo)
+	})
+	m.state = "list"
+
+	// Reset form
+	m.textInput.Reset()
+	m.descInput.Reset()
+	m.categoryInput.Reset()
+	m.priority = "low"
+	m.dueDate = ""
+
+	return m
+}
+
+func (m model) updateTodo(id int, title string) model {
+	for i, todo := range m.todos {
+		if todo.ID == id {
+			m.todos[i].Title = title
+			break
+		}
+	}
+
+	m = m.updateList()
+	m = m.setMessage(fmt.Sprintf("Updated: %s", title), "success")
+	m = m.commit(func(tx store.Tx) error {
+		todo, err := tx.Get(id)
+		if err != nil {
+			return err
+		}
+		todo.Title = title
+		return tx.Put(todo)
+	})
+
+	return m
+}
+
+func (m model) deleteTodo(id int) model {
+	for i, todo := range m.todos {
+		if todo.ID == id {
+			title := todo.Title
+			m.todos = append(m.todos[:i], m.todos[i+1:]...)
+			m = m.updateList()
+			m = m.setMessage(fmt.Sprintf("Deleted: %s", title), "success")
+			m = m.commit(func(tx store.Tx) error {
+				err := tx.Delete(id)
+				if errors.Is(err, store.ErrNotFound) {
+					return nil
+				}
+				return err
+			})
+			break
+		}
+	}
+
+	return m
+}
+
+func (m model) toggleTodo(id int) model {
+	for i, todo := range m.todos {
+		if todo.ID == id {
+			m.todos[i].Completed = !m.todos[i].Completed
+			status := "completed"
+			if m.todos[i].Completed {
+				now := time.Now()
+				m.todos[i].CompletedAt = &now
+			} else {
+				m.todos[i].CompletedAt = nil
+				status = "pending"
+			}
+			toggled := m.todos[i]
+			m = m.updateList()
+			m = m.setMessage(fmt.Sprintf("Marked as %s: %s", status, todo.Title), "success")
+			m = m.commit(func(tx store.Tx) error {
+				todo, err := tx.Get(id)
+				if err != nil {
+					return err
+				}
+				todo.Completed = toggled.Completed
+				todo.CompletedAt = toggled.CompletedAt
+				return tx.Put(todo)
+			})
+			break
+		}
+	}
+
+	return m
+}
+
+// sortTodos switches the view to priority order. The order is not stored;
+// each backend lists todos by ID.
+func (m model) sortTodos() model {
+	m.sorted = true
+	m = m.orderTodos()
+	m = m.updateList()
+	m = m.setMessage("Todos sorted by priority and due date", "info")
+
+	return m
+}
+
+func (m model) orderTodos() model {
+	todo.Sort(m.todos, todo.OrderPriority)
+	return m
+}
+
+func (m model) showCategories() model {
+	categories := make(map[string]int)
+	for _, todo := range m.todos {
+		if todo.Category != "" {
+			categories[todo.Category]++
+		}
+	}
+
+	if len(categories) == 0 {
+		m = m.setMessage("No categories found", "info")
+		return m
+	}
+
+	var categoryList []string
+	for cat, count := range categories {
+		categoryList = append(categoryList, fmt.Sprintf("%s: %d todos", cat, count))
+	}
+
+	m = m.setMessage(fmt.Sprintf("Categories: %s", strings.Join(categoryList, ", ")), "info")
+	return m
+}
+
+// pickList opens the list picker, to switch lists or, when id is not 0,
+// to move that todo to another list
+func (m model) pickList(id int) model {
+	names, err := m.cfg.Lists()
+	if err != nil {
+		return m.setMessage(fmt.Sprintf("Could not find lists: %v", err), "error")
+	}
+
+	m.lists = names
+	m.listIndex = 0
+	for i, name := range names {
+		if name == m.listName {
+			m.listIndex = i
+		}
+	}
+	m.movingID = id
+	m.state = "pick_list"
+	return m.setMessage("", "")
+}
+
+// chooseList acts on the list picked in "pick_list" or "new_list"
+func (m model) chooseList(name string) model {
+	m.state = "list"
+	if m.movingID != 0 {
+		return m.moveTodo(m.movingID, name)
+	}
+	return m.switchList(name)
+}
+
+// switchList closes the current list and opens another one
+func (m model) switchList(name string) model {
+	if name == m.listName {
+		return m.setMessage("", "")
+	}
+	if m.sync.running {
+		// The sync is still using the current list's store
+		return m.setMessage(fmt.Sprintf("Wait for the sync with %s to finish before switching lists", m.sync.name), "error")
+	}
+	if m.dirty {
+		if m = m.save(); m.dirty {
+			return m.setMessage(m.message+" (switching lists needs the changes saved first)", "error")
+		}
+	}
+
+	if m.store != nil {
+		m.store.Close()
+	}
+	m.cfg.List = name
+	st, path, err := m.cfg.OpenStore()
+	m.store, m.storePath, m.listName = st, path, name
+	m.list.Title = listTitle(name)
+
+	todos, nextID, loadErr := []todo.Todo{}, 1, err
+	var saved *todo.TodoList
+	if err == nil {
+		todos, nextID, saved, loadErr = loadTodos(st)
+	}
+	if loadErr != nil {
+		m.state = "load_error"
+		m.loadErr = loadErr
+		return m.setMessage("", "")
+	}
+
+	m.todos = todos
+	m.nextID = nextID
+	m.saved = saved
+	if m.sorted {
+		m = m.orderTodos()
+	}
+	m = m.updateList()
+	m.list.Select(0)
+	m = m.refreshSyncStatus()
+	return m.setMessage(fmt.Sprintf("Switched to list %s", name), "info")
+}
+
+// moveTodo moves a todo from the current list to the named one
+func (m model) moveTodo(id int, name string) model {
+	if name == m.listName {
+		return m.setMessage(fmt.Sprintf("Todo #%d is already in %s", id, name), "info")
+	}
+	if m.dirty {
+		if m = m.save(); m.dirty {
+			return m
+		}
+	}
+
+	dst, _, err := m.cfg.OpenList(name)
+	if err != nil {
+		return m.setMessage(fmt.Sprintf("Could not open list %s: %v", name, err), "error")
+	}
+	defer dst.Close()
+
+	moved, err := store.Move(outbox.WithNote(dst, "moved from "+m.listName), outbox.WithNote(m.store, "moved to "+name), id)
+	m = m.reload()
+	if err != nil {
+		return m.setMessage(fmt.Sprintf("Move failed: %v", err), "error")
+	}
+	return m.setMessage(fmt.Sprintf("Moved to %s: %s", name, moved.Title), "success")
+}
+
+// listTitle is the heading shown above a named list
+func listTitle(name string) string {
+	if name == config.DefaultList {
+		return "📝 Advanced Todo List"
+	}
+	return "📝 Advanced Todo List · " + name
+}
+
+// applyFilter parses text as a query and shows only the todos it matches.
+// An invalid query is reported and the previous filter kept.
+func (m model) applyFilter(text string) model {
+	query, err := todo.ParseQuery(text)
+	if err != nil {
+		return m.setMessage(err.Error(), "error")
+	}
+	m.query = query
+	m.queryText = strings.TrimSpace(text)
+	m = m.setMessage("", "")
+	return m.updateList()
+}
+
+func (m model) updateList() model {
+	todos := m.query.Filter(m.todos)
+	items := make([]list.Item, len(todos))
+	for i, todo := range todos {
+		items[i] = todoItem{todo: todo}
+	}
+	m.list.SetItems(items)
+	return m
+}
+
+func (m model) setMessage(text, msgType string) model {
+	m.message = text
+	m.messageType = msgType
+	return m
+}
+
+func (m model) messageView() string {
+	var style lipgloss.Style
+	switch m.messageType {
+	case "error":
+		style = errorStyle
+	case "success":
+		style = successStyle
+	case "info":
+		style = infoStyle
+	default:
+		style = helpStyle
+	}
+	return style.Render(m.message)
+}
+
+// commit writes a change, already applied to m.todos, to the store and then
+// reloads so the view also picks up changes made by other processes. On
+// failure the error replaces the status message and the model stays dirty;
+// the next commit or quit then writes the whole in-memory list instead, as
+// long as no other process has written the store since it was last read.
+// If one has, the earlier unsaved changes are dropped and only this one is
+// made.
+func (m model) commit(change func(tx store.Tx) error) model {
+	var err error
+	dropped := false
+	if m.dirty {
+		err = m.saveTodos()
+		if errors.Is(err, store.ErrChanged) {
+			dropped = true
+			err = m.store.Update(change)
+		}
+	} else {
+		err = m.store.Update(change)
+	}
+	if err != nil {
+		m.dirty = true
+		return m.setMessage(fmt.Sprintf("Save failed: %v", err), "error")
+	}
+
+	m.dirty = false
+	m.quitPending = false
+	m = m.reload().refreshSyncStatus()
+	if dropped {
+		return m.setMessage(m.message+" (earlier unsaved changes were dropped, as the list was changed elsewhere)", "error")
+	}
+	return m
+}
+
+// save writes the whole in-memory list, used to retry after a failure. If
+// another process has written the store since it was last read, the
+// unsaved changes are dropped and the store's contents shown instead.
+func (m model) save() model {
+	err := m.saveTodos()
+	if errors.Is(err, store.ErrChanged) {
+		m.dirty = false
+		m.quitPending = false
+		m = m.reload()
+		return m.setMessage("The list was changed elsewhere, so the unsaved changes were dropped and it was reloaded", "error")
+	}
+	if err != nil {
+		m.dirty = true
+		return m.setMessage(fmt.Sprintf("Save failed: %v", err), "error")
+	}
+	m.dirty = false
+	m.quitPending = false
+	return m.reload()
+}
+
+// reload replaces the in-memory todos with the store's contents
+func (m model) reload() model {
+	todos, nextID, saved, err := loadTodos(m.store)
+	if err != nil {
+		return m
+	}
+	m.todos = todos
+	m.nextID = nextID
+	m.saved = saved
+	if m.sorted {
+		m = m.orderTodos()
+	}
+	return m.updateList()
+}
+
+// Load error recovery
+func (m model) retryLoad() model {
+	if m.store == nil {
+		st, err := m.cfg.OpenRecorded(m.storeBackend, m.storePath)
+		if err != nil {
+			m.loadErr = err
+			return m.setMessage("Still unable to open the store", "error")
+		}
+		m.store = st
+	}
+
+	todos, nextID, saved, err := loadTodos(m.store)
+	if err != nil {
+		m.loadErr = err
+		return m.setMessage("Still unable to load todos", "error")
+	}
+
+	m.todos = todos
+	m.nextID = nextID
+	m.saved = saved
+	m.loadErr = nil
+	m.state = "list"
+	m = m.updateList()
+	return m.setMessage("Todos loaded", "success")
+}
+
+// reopen sets the unreadable data file aside and opens an empty store in
+// its place, returning where the old file went
+func (m model) reopen() (model, string, error) {
+	if m.sync.running {
+		return m, "", fmt.Errorf("the sync with %s is still using the file; try again when it finishes", m.sync.name)
+	}
+	if m.store != nil {
+		m.store.Close()
+		m.store = nil
+	}
+
+	aside, err := todo.SetAside(m.storePath)
+	if err != nil {
+		return m, "", fmt.Errorf("could not move the unreadable file aside: %w", err)
+	}
+
+	st, err := m.cfg.OpenRecorded(m.storeBackend, m.storePath)
+	if err != nil {
+		return m, aside, err
+	}
+	m.store = st
+	if m.saved, err = store.SnapshotAll(st); err != nil {
+		return m, aside, err
+	}
+	return m, aside, nil
+}
+
+func (m model) startFresh() model {
+	m, aside, err := m.reopen()
+	if err != nil {
+		return m.setMessage(err.Error(), "error")
+	}
+
+	m.todos = []todo.Todo{}
+	m.nextID = 1
+	m.loadErr = nil
+	m.state = "list"
+	m = m.updateList()
+	m = m.setMessage(fmt.Sprintf("Started a fresh list; the old file was kept as %s", aside), "info")
+	return m.save()
+}
+
+func (m model) openBackup(path string) model {
+	todoList, err := todo.Read(path)
+	if err != nil {
+		return m.setMessage(fmt.Sprintf("Could not open %s: %v", path, err), "error")
+	}
+
+	m, aside, err := m.reopen()
+	if err != nil {
+		return m.setMessage(err.Error(), "error")
+	}
+
+	m.todos = todo.Live(todoList.Todos)
+	m.nextID = todoList.NextID
+	m.loadErr = nil
+	m.state = "list"
+	m = m.updateList()
+	m = m.setMessage(fmt.Sprintf("Restored %d todos from %s; the old file was kept as %s", len(m.todos), path, aside), "success")
+	return m.save()
+}
+
+// File operations
+
+// loadTodos reads the store, returning its live todos and next ID along
+// with the whole of it, tombstones included, for saveTodos to check
+// against
+func loadTodos(st store.Store) (todos []todo.Todo, nextID int, saved *todo.TodoList, err error) {
+	saved, err = store.SnapshotAll(st)
+	if err != nil {
+		return []todo.Todo{}, 1, nil, err
+	}
+
+	return todo.Live(saved.Todos), saved.NextID, saved, nil
+}
+
+// saveTodos writes the whole in-memory list, returning store.ErrChanged
+// if another process has written the store since it was last read
+func (m model) saveTodos() error {
+	todoList := &todo.TodoList{
+		Todos:  m.todos,
+		NextID: m.nextID,
+	}
+
+	return store.CompareAndReplace(m.store, m.saved, todoList)
+}
+
+func main() {
+	file := flag.String("file", "", "todo data file (overrides $"+config.FileEnv+" and the config file)")
+	listName := flag.String("list", "", "named list to open")
+	remoteName := flag.String("remote", "", "remote to sync with in the background (default: the default remote)")
+	interval := flag.String("sync-interval", "", "how often to sync with the remote, such as 5m, or 0 for never (overrides sync.interval in the config file)")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *file != "" {
+		if err := cfg.SetFile(*file); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *listName != "" {
+		if *listName != config.DefaultList {
+			if err := config.ValidateListName(*listName); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		cfg.List = *listName
+	}
+
+	if *interval != "" {
+		cfg.Sync.Interval = *interval
+	}
+	every, err := cfg.SyncInterval()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	sync, syncErr := newAutoSync(cfg, *remoteName, every)
+
+	m := initialModel(cfg, sync)
+	if syncErr != nil {
+		m = m.setMessage(fmt.Sprintf("Background sync is off: %v", syncErr), "error")
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Printf("Error: %v", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"todo-bubbletea/client"
+	"todo-bubbletea/config"
+	"todo-bubbletea/merge"
+	"todo-bubbletea/store"
+)
+
+// autoSync is the background sync with a todo server. A zero autoSync is
+// off.
+type autoSync struct {
+	remote   client.Config
+	name     string // remote name shown in the status bar
+	interval time.Duration
+	gen      int // only the tick from the latest schedule starts a sync
+
+	running    bool
+	lastSynced time.Time
+	pending    int   // local changes the next sync sends
+	conflicts  int   // conflicts left for 'todo sync' to resolve
+	err        error // why the last sync failed
+}
+
+// syncTickMsg asks for a sync, unless a later schedule replaced it
+type syncTickMsg struct {
+	gen int
+}
+
+// syncDoneMsg reports a background sync of the list in storePath
+type syncDoneMsg struct {
+	storePath string
+	result    *merge.Result
+	err       error
+}
+
+// newAutoSync sets up syncing with the named remote, or the default one,
+// every interval. It returns a zero autoSync when there is no remote or
+// interval is 0. The password or token has to come from the environment
+// or a password command, as the TUI cannot prompt for it.
+func newAutoSync(cfg *config.Config, name string, interval time.Duration) (autoSync, error) {
+	if name == "" {
+		name = cfg.DefaultRemoteName()
+	}
+	if name == "" || interval == 0 {
+		return autoSync{}, nil
+	}
+	remote, ok := cfg.Remotes[name]
+	if !ok {
+		return autoSync{}, fmt.Errorf("no remote named %s", name)
+	}
+
+	secret, err := remote.Secret()
+	if err != nil {
+		return autoSync{}, err
+	}
+	if secret == "" && (remote.Bearer() || remote.User != "") {
+		if remote.PasswordEnv != "" {
+			return autoSync{}, fmt.Errorf("remote %s needs %s set to sync from the TUI", name, remote.PasswordEnv)
+		}
+		return autoSync{}, fmt.Errorf("remote %s needs %s, a password_env or a password_command to sync from the TUI", name, remote.SecretEnv())
+	}
+	c, err := client.ForRemote(remote, secret)
+	if err != nil {
+		return autoSync{}, fmt.Errorf("remote %s: %w", name, err)
+	}
+	return autoSync{remote: c, name: name, interval: interval}, nil
+}
+
+func (s autoSync) enabled() bool {
+	return s.interval > 0
+}
+
+// scheduleSync starts the wait for the next sync
+func (m model) scheduleSync() (model, tea.Cmd) {
+	if !m.sync.enabled() {
+		return m, nil
+	}
+	m.sync.gen++
+	gen := m.sync.gen
+	return m, tea.Tick(m.sync.interval, func(time.Time) tea.Msg {
+		return syncTickMsg{gen: gen}
+	})
+}
+
+// startSync syncs the current list in the background. While there are
+// unsaved changes or the list could not be loaded it waits for the next
+// tick instead.
+func (m model) startSync() (model, tea.Cmd) {
+	if !m.sync.enabled() || m.sync.running {
+		return m, nil
+	}
+	if m.store == nil || m.dirty || m.loadErr != nil {
+		return m.scheduleSync()
+	}
+	basePath, err := m.cfg.SyncBasePath(m.sync.remote.ServerURL)
+	if err != nil {
+		m.sync.err = err
+		return m.scheduleSync()
+	}
+
+	// A tick already scheduled is superseded by this sync
+	m.sync.gen++
+	m.sync.running = true
+	remote, st, path := m.sync.remote, m.store, m.storePath
+	return m, func() tea.Msg {
+		// Conflicts are left for 'todo sync', which can ask about them
+		result, err := client.Sync(remote, st, basePath, nil)
+		return syncDoneMsg{storePath: path, result: result, err: err}
+	}
+}
+
+// finishSync takes in the outcome of a background sync, showing the
+// server's changes, and schedules the next one
+func (m model) finishSync(msg syncDoneMsg) (model, tea.Cmd) {
+	m.sync.running = false
+	if msg.storePath != m.storePath {
+		// Switching lists waits for the sync, so this is only a safeguard
+		m = m.refreshSyncStatus()
+		return m.scheduleSync()
+	}
+
+	switch {
+	case msg.err == nil:
+		m.sync.err = nil
+		m.sync.conflicts = 0
+		if !m.dirty {
+			m = m.reload()
+		}
+	case errors.Is(msg.err, merge.ErrConflict):
+		m.sync.err = nil
+		m.sync.conflicts = len(msg.result.Conflicts)
+	case errors.Is(msg.err, store.ErrChanged):
+		// A todo was changed here while the sync ran; the next one takes it
+	default:
+		m.sync.err = msg.err
+	}
+	m = m.refreshSyncStatus()
+	return m.scheduleSync()
+}
+
+// refreshSyncStatus rereads when the current list was last synced and how
+// many local changes wait for the next sync
+func (m model) refreshSyncStatus() model {
+	if !m.sync.enabled() || m.store == nil {
+		return m
+	}
+	basePath, err := m.cfg.SyncBasePath(m.sync.remote.ServerURL)
+	if err != nil {
+		return m
+	}
+	base, pending, err := client.Status(m.store, basePath)
+	if err != nil {
+		return m
+	}
+	m.sync.lastSynced = time.Time{}
+	if base != nil {
+		m.sync.lastSynced = base.SyncedAt
+	}
+	m.sync.pending = len(pending)
+	return m
+}
+
+// syncView is the sync line of the status bar
+func (m model) syncView() string {
+	if !m.sync.enabled() {
+		return ""
+	}
+
+	details := "never synced"
+	if !m.sync.lastSynced.IsZero() {
+		details = "last synced " + m.sync.lastSynced.Local().Format("15:04:05")
+		if time.Since(m.sync.lastSynced) > 24*time.Hour {
+			details = "last synced " + m.sync.lastSynced.Local().Format("Jan 2 15:04")
+		}
+	}
+	if m.sync.pending > 0 {
+		details += fmt.Sprintf(" · %d pending", m.sync.pending)
+	}
+
+	switch {
+	case m.sync.running:
+		return infoStyle.Render(fmt.Sprintf("⟳ Syncing with %s… (%s)", m.sync.name, details))
+	case m.sync.conflicts > 0:
+		conflicts := fmt.Sprintf("%d conflicts", m.sync.conflicts)
+		if m.sync.conflicts == 1 {
+			conflicts = "1 conflict"
+		}
+		return warningStyle.Render(fmt.Sprintf("⚠ %s with %s; run 'todo sync %s' to resolve (%s)", conflicts, m.sync.name, m.sync.name, details))
+	case errors.Is(m.sync.err, client.ErrUnreachable):
+		return warningStyle.Render(fmt.Sprintf("✗ %s is unreachable; changes wait here (%s)", m.sync.name, details))
+	case m.sync.err != nil:
+		return errorStyle.Render(fmt.Sprintf("✗ Sync with %s failed: %v (%s)", m.sync.name, m.sync.err, details))
+	}
+	return helpStyle.Render(fmt.Sprintf("✓ Synced with %s (%s)", m.sync.name, details))
+}
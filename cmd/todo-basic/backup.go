@@ -0,0 +1,730 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"google.golang.org/api/drive/v3"
+
+	"todo-bubbletea/backup"
+	"todo-bubbletea/client"
+	"todo-bubbletea/config"
+	"todo-bubbletea/gdrive"
+	"todo-bubbletea/merge"
+	"todo-bubbletea/outbox"
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+// errNoBackup reports a list with no backup to restore
+var errNoBackup = errors.New("no backup")
+
+func newBackupCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Inspect the backups of the current list",
+		Long: `Every upload adds a snapshot of the current list to where backup.type in
+the config file says backups are kept, then deletes the ones the
+retention policy no longer covers. By default it keeps the 10 newest
+snapshots, the newest of each day for a week and the newest of each month
+for a year; backup.keep.last, backup.keep.daily and backup.keep.monthly
+change that.
+
+Backups go to the drive.folder folder in Google Drive ("todo backups" by
+default) unless backup.type is one of:
+
+  webdav  the collection at backup.url, as backup.user
+  s3      backup.bucket at the backup.url endpoint, in backup.region,
+          under the key prefix backup.path, with backup.user as the
+          access key ID
+  dir     the directory backup.path, relative to the data directory
+  git     the git repository backup.path, committing every change and
+          pushing it too if backup.push is true
+
+The WebDAV password or S3 secret key comes from TODO_BACKUP_PASSWORD,
+backup.password_env or backup.password_command, and is asked for on a
+terminal otherwise.
+
+Restore --at brings back the list as it was at a given time.`,
+		Example: `  todo upload
+  todo backup list
+  todo restore --at 2026-10-01`,
+	}
+	cmd.AddCommand(newBackupListCmd(a))
+	return cmd
+}
+
+func newBackupListCmd(a *app) *cobra.Command {
+	var out output
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "Show the current list's backups",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := a.config()
+			if err != nil {
+				return err
+			}
+			return withBackups(cfg, func(remote backup.Remote) error {
+				versions, err := remote.List(cfg.CurrentList())
+				if err != nil {
+					return err
+				}
+				return out.backups(backupVersions(versions), func() {
+					printBackups(cfg.CurrentList(), remote, versions)
+				})
+			})
+		},
+	}
+	out.register(cmd)
+	return cmd
+}
+
+// backupVersion describes one snapshot for 'todo backup list'
+type backupVersion struct {
+	ID   string    `json:"id" yaml:"id"`
+	Name string    `json:"name" yaml:"name"`
+	List string    `json:"list" yaml:"list"`
+	Time time.Time `json:"time" yaml:"time"`
+	// Todos is nil when the backup does not say how many it holds
+	Todos *int  `json:"todos" yaml:"todos"`
+	Size  int64 `json:"size" yaml:"size"`
+}
+
+var backupColumns = []string{"id", "name", "list", "time", "todos", "size"}
+
+func backupRow(b backupVersion) []string {
+	todos := ""
+	if b.Todos != nil {
+		todos = strconv.Itoa(*b.Todos)
+	}
+	return []string{b.ID, b.Name, b.List, b.Time.Format(time.RFC3339), todos, strconv.FormatInt(b.Size, 10)}
+}
+
+func backupVersions(versions []backup.Version) []backupVersion {
+	list := make([]backupVersion, 0, len(versions))
+	for _, v := range versions {
+		b := backupVersion{ID: v.ID, Name: v.Name, List: v.List, Time: v.Time, Size: v.Size}
+		if v.Todos >= 0 {
+			b.Todos = &v.Todos
+		}
+		list = append(list, b)
+	}
+	return list
+}
+
+// printBackups lists the backups of list in remote, newest first
+func printBackups(list string, remote backup.Remote, backups []backup.Version) {
+	if len(backups) == 0 {
+		printInfo(fmt.Sprintf("No backups of list %s in %s yet; make one with 'todo upload'", list, remote))
+		return
+	}
+
+	fmt.Printf("%s%s🗄️  Backups of %s in %s%s\n", ColorYellow, ColorBold, list, remote, ColorReset)
+	fmt.Println()
+	fmt.Printf("   %-20s %6s %9s\n", "TAKEN", "TODOS", "SIZE")
+	for _, b := range backups {
+		todos := "?"
+		if b.Todos >= 0 {
+			todos = fmt.Sprint(b.Todos)
+		}
+		fmt.Printf("   %-20s %6s %9s\n", b.Time.Local().Format("2006-01-02 15:04:05"), todos, formatSize(b.Size))
+	}
+	fmt.Println()
+}
+
+// formatSize shows a file size in bytes, KB or MB
+func formatSize(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+}
+
+func newRestoreCmd(a *app) *cobra.Command {
+	var at string
+	var force bool
+	var out output
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Replace todos with an earlier backup",
+		Long: `Restore replaces the current list with the newest backup taken no
+later than --at, or the newest of all without it. A date on its own
+means the end of that day, so --at 2026-10-01 restores the list as it
+was when that day ended.
+
+Changes made here since the last upload or download would be lost, so
+restore refuses to run while there are any unless given --force.`,
+		Example: `  todo restore --at 2026-10-01
+  todo restore --at "2026-10-01 09:00"
+  todo --list work restore --at yesterday`,
+		Args: cobra.NoArgs,
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			when := time.Now()
+			if at != "" {
+				var err error
+				if when, err = parseBackupTime(at); err != nil {
+					return err
+				}
+			}
+			result, err := restoreBackup(a.cfg, st, when, force, &out)
+			if err != nil {
+				return err
+			}
+			return out.restore(result, result.print)
+		}),
+	}
+	out.register(cmd)
+	cmd.Flags().StringVar(&at, "at", "", "restore the backup from this date or time (YYYY-MM-DD [HH:MM], today or yesterday)")
+	cmd.Flags().BoolVar(&force, "force", false, "restore even if local changes have not been uploaded")
+	cmd.RegisterFlagCompletionFunc("at", cobra.NoFileCompletions)
+	return cmd
+}
+
+// parseBackupTime reads --at as the time to restore the list to, in the
+// local time zone. A day means its end.
+func parseBackupTime(value string) (time.Time, error) {
+	now := time.Now()
+	endOfDay := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond)
+	}
+	switch strings.ToLower(value) {
+	case "today":
+		return endOfDay(now), nil
+	case "yesterday":
+		return endOfDay(now.AddDate(0, 0, -1)), nil
+	}
+	if day, err := time.ParseInLocation(dueLayout, value, time.Local); err == nil {
+		return endOfDay(day), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use YYYY-MM-DD, YYYY-MM-DD HH:MM, today or yesterday", value)
+}
+
+// uploadResult describes the backup 'todo upload' made
+type uploadResult struct {
+	ID   string `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+	List string `json:"list" yaml:"list"`
+	// Target describes where the backups are kept
+	Target string    `json:"target" yaml:"target"`
+	Time   time.Time `json:"time" yaml:"time"`
+	Todos  int       `json:"todos" yaml:"todos"`
+	// Pruned counts the older backups the retention policy removed
+	Pruned int `json:"pruned" yaml:"pruned"`
+}
+
+var uploadColumns = []string{"id", "name", "list", "target", "time", "todos", "pruned"}
+
+func uploadRow(r uploadResult) []string {
+	return []string{r.ID, r.Name, r.List, r.Target, r.Time.Format(time.RFC3339), strconv.Itoa(r.Todos), strconv.Itoa(r.Pruned)}
+}
+
+// print tells people what the upload did
+func (r uploadResult) print() {
+	printSuccess(fmt.Sprintf("Backed up %d todos to %s as '%s'", r.Todos, r.Target, r.Name))
+	switch r.Pruned {
+	case 0:
+	case 1:
+		printInfo("Removed 1 old backup")
+	default:
+		printInfo(fmt.Sprintf("Removed %d old backups", r.Pruned))
+	}
+}
+
+// uploadBackup backs up the todos in st, tombstones included so that
+// downloads elsewhere see the deletions, and records them as what the
+// backups hold
+func uploadBackup(cfg *config.Config, st store.Store, out *output) (uploadResult, error) {
+	basePath, err := backupBasePath(cfg)
+	if err != nil {
+		return uploadResult{}, err
+	}
+	out.progress(fmt.Sprintf("Uploading todos to %s...", backupTarget(cfg)))
+	todoList, err := store.SnapshotAll(st)
+	if err != nil {
+		return uploadResult{}, fmt.Errorf("loading todos: %w", err)
+	}
+	live := len(todo.Live(todoList.Todos))
+
+	// Convert todos to JSON
+	jsonData, err := json.MarshalIndent(todoList, "", "  ")
+	if err != nil {
+		return uploadResult{}, fmt.Errorf("failed to marshal todos: %w", err)
+	}
+
+	list := cfg.CurrentList()
+	var result uploadResult
+	err = withBackups(cfg, func(remote backup.Remote) error {
+		v, err := remote.Put(backup.Version{List: list, Time: time.Now(), Todos: live}, jsonData)
+		if err != nil {
+			return fmt.Errorf("failed to upload backup: %w", err)
+		}
+		if err := merge.SaveBase(basePath, &merge.Base{List: todoList, SyncedAt: time.Now()}); err != nil {
+			return fmt.Errorf("recording upload: %w", err)
+		}
+		result = uploadResult{ID: v.ID, Name: v.Name, List: list, Target: remote.String(), Time: v.Time, Todos: live}
+		result.Pruned = pruneBackups(remote, list, cfg.Backup.Keep)
+		return nil
+	})
+	return result, err
+}
+
+// pruneBackups deletes the backups of list that keep does not cover and
+// returns how many it removed. The upload already succeeded, so failures
+// are only warned about.
+func pruneBackups(remote backup.Remote, list string, keep config.BackupRetention) int {
+	all, err := remote.List(list)
+	if err != nil {
+		printWarning(fmt.Sprintf("Could not list old backups to remove: %v", err))
+		return 0
+	}
+	removed := 0
+	for _, b := range backup.Prune(all, keep, time.Now()) {
+		err := remote.Delete(b)
+		if errors.Is(err, backup.ErrNotFound) {
+			// Already pruned, from another machine
+			continue
+		}
+		if err != nil {
+			printWarning(fmt.Sprintf("Could not remove old backup '%s': %v", b.Name, err))
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+// downloadResult describes what 'todo download' merged, or with
+// --dry-run would merge
+type downloadResult struct {
+	// From describes the backup merged, and is empty if there was none
+	From   string `json:"from" yaml:"from"`
+	DryRun bool   `json:"dry_run" yaml:"dry_run"`
+	// Changes lists what the download changed here
+	Changes []pendingChange `json:"changes" yaml:"changes"`
+	// Conflicts counts the fields changed both here and in the backup.
+	// Without --force they stop the download.
+	Conflicts int `json:"conflicts" yaml:"conflicts"`
+	// Renumbered lists the todos from the backup whose IDs were taken here
+	Renumbered []renumbered `json:"renumbered,omitempty" yaml:"renumbered,omitempty"`
+
+	conflicts  []merge.Conflict
+	conflicted bool
+}
+
+// downloadColumns has one row per download, so changes is their number;
+// JSON and YAML list them
+var downloadColumns = []string{"from", "dry_run", "changes", "conflicts", "renumbered"}
+
+func downloadRow(r downloadResult) []string {
+	var moves []string
+	for _, m := range r.Renumbered {
+		moves = append(moves, fmt.Sprintf("%d:%d", m.From, m.To))
+	}
+	return []string{r.From, strconv.FormatBool(r.DryRun), strconv.Itoa(len(r.Changes)), strconv.Itoa(r.Conflicts), strings.Join(moves, " ")}
+}
+
+// print tells people what the download did, or would do
+func (r downloadResult) print() {
+	switch {
+	case r.From == "":
+		return
+	case r.DryRun:
+		printDownloadPreview(r.From, r.Changes, r.conflicts, r.conflicted)
+		return
+	}
+	for _, m := range r.Renumbered {
+		printInfo(fmt.Sprintf("Todo #%d from the backup is #%d here, as #%d was taken", m.From, m.To, m.From))
+	}
+	if r.Conflicts > 0 {
+		printInfo(fmt.Sprintf("Took the backup's side in %d conflicts", r.Conflicts))
+	}
+	if len(r.Changes) == 0 {
+		printSuccess(fmt.Sprintf("Already up to date with %s", r.From))
+		return
+	}
+	printSuccess(fmt.Sprintf("Merged %s: %d todos changed here", r.From, len(r.Changes)))
+}
+
+// downloadBackup merges the newest backup into the local todos,
+// as sync does with a server: changes made on either side since the last
+// upload or download are both kept. Local changes that conflict with the
+// backup stop it unless force, which takes the backup's side. With dryRun
+// it only works out what would change.
+func downloadBackup(cfg *config.Config, st store.Store, dryRun, force bool, out *output) (downloadResult, error) {
+	result := downloadResult{DryRun: dryRun, Changes: []pendingChange{}}
+	basePath, err := backupBasePath(cfg)
+	if err != nil {
+		return result, err
+	}
+	out.progress(fmt.Sprintf("Downloading todos from %s...", backupTarget(cfg)))
+	base, err := merge.LoadBase(basePath)
+	if err != nil {
+		return result, fmt.Errorf("reading last downloaded copy: %w", err)
+	}
+	local, err := store.SnapshotAll(st)
+	if err != nil {
+		return result, fmt.Errorf("loading todos: %w", err)
+	}
+	remote, from, err := fetchBackup(cfg, time.Time{})
+	if errors.Is(err, errNoBackup) {
+		printWarning(fmt.Sprintf("No backups of list %s found in %s", cfg.CurrentList(), backupTarget(cfg)))
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	var baseList *todo.TodoList
+	if base != nil {
+		baseList = base.List
+	}
+	var resolve merge.Resolver
+	if force {
+		resolve = merge.Prefer(merge.Remote)
+	}
+	merged, err := merge.Merge(baseList, local, remote, resolve)
+	conflicted := errors.Is(err, merge.ErrConflict)
+	if err != nil && !conflicted {
+		return result, err
+	}
+	result.From = from
+	result.Changes = pendingChanges(localChanges(local, merged.List))
+	result.Conflicts = len(merged.Conflicts)
+	result.conflicts, result.conflicted = merged.Conflicts, conflicted
+	for from, to := range merged.Renumbered {
+		result.Renumbered = append(result.Renumbered, renumbered{From: from, To: to})
+	}
+	slices.SortFunc(result.Renumbered, func(a, b renumbered) int { return a.From - b.From })
+
+	if dryRun {
+		return result, nil
+	}
+	if conflicted {
+		printConflicts(merged.Conflicts)
+		return result, fmt.Errorf("%w; nothing was changed. Upload the local changes first, or pass --force to take the backup's side", err)
+	}
+
+	if err := store.CompareAndReplace(outbox.WithSource(st, basePath), local, merged.List); err != nil {
+		if errors.Is(err, store.ErrChanged) {
+			return result, fmt.Errorf("local %w during download; run download again", err)
+		}
+		return result, fmt.Errorf("failed to save local file: %w", err)
+	}
+	// The backup is what the backups hold now, so the next download only
+	// takes what changed there since
+	if err := merge.SaveBase(basePath, &merge.Base{List: remote, SyncedAt: time.Now()}); err != nil {
+		return result, fmt.Errorf("recording download: %w", err)
+	}
+	return result, nil
+}
+
+// localChanges returns what replacing local with merged changes, including
+// todos merged leaves out, which are deleted
+func localChanges(local, merged *todo.TodoList) []merge.Pending {
+	changes := merge.Diff(local, merged)
+	kept := make(map[string]bool, len(merged.Todos))
+	for _, t := range merged.Todos {
+		kept[t.UUID] = true
+	}
+	for _, t := range todo.Live(local.Todos) {
+		if !kept[t.UUID] {
+			changes = append(changes, merge.Pending{Todo: t, Change: merge.Deleted})
+		}
+	}
+	return changes
+}
+
+// printDownloadPreview shows what a download would change here
+func printDownloadPreview(from string, changes []pendingChange, conflicts []merge.Conflict, conflicted bool) {
+	fmt.Printf("%s%s🔍 Download preview%s %s(%s)%s\n", ColorYellow, ColorBold, ColorReset, ColorDim, from, ColorReset)
+	fmt.Println()
+	switch {
+	case len(changes) == 0 && conflicted:
+		fmt.Println("   No other changes")
+	case len(changes) == 0:
+		fmt.Println("   No changes")
+	}
+	printPending(changes)
+	fmt.Println()
+	if conflicted {
+		printConflicts(conflicts)
+		printWarning("These conflict with changes not uploaded yet, so download would stop; --force takes the backup's side")
+	} else if len(conflicts) > 0 {
+		printInfo(fmt.Sprintf("--force takes the backup's side in %d conflicts", len(conflicts)))
+	}
+}
+
+// restoreResult describes the backup 'todo restore' brought back
+type restoreResult struct {
+	// From describes the backup restored
+	From  string `json:"from" yaml:"from"`
+	List  string `json:"list" yaml:"list"`
+	Todos int    `json:"todos" yaml:"todos"`
+}
+
+var restoreColumns = []string{"from", "list", "todos"}
+
+func restoreRow(r restoreResult) []string {
+	return []string{r.From, r.List, strconv.Itoa(r.Todos)}
+}
+
+// print tells people what was restored
+func (r restoreResult) print() {
+	printSuccess(fmt.Sprintf("Restored %d todos from %s", r.Todos, r.From))
+}
+
+// restoreBackup replaces the todos in st with the newest backup taken no
+// later than at. Local changes not uploaded yet stop it unless force.
+func restoreBackup(cfg *config.Config, st store.Store, at time.Time, force bool, out *output) (restoreResult, error) {
+	if !force {
+		basePath, err := backupBasePath(cfg)
+		if err != nil {
+			return restoreResult{}, err
+		}
+		if _, pending, err := client.Status(st, basePath); err != nil {
+			return restoreResult{}, err
+		} else if len(pending) > 0 {
+			return restoreResult{}, fmt.Errorf("%d local changes have not been uploaded and restore would lose them; upload them first, or pass --force", len(pending))
+		}
+	}
+	out.progress(fmt.Sprintf("Looking for the backup of %s from %s...", cfg.CurrentList(), at.Local().Format("2006-01-02 15:04")))
+
+	todoList, from, err := fetchBackup(cfg, at)
+	if err != nil {
+		return restoreResult{}, err
+	}
+	if err := saveTodos(st, todoList); err != nil {
+		return restoreResult{}, fmt.Errorf("failed to save local file: %w", err)
+	}
+	return restoreResult{From: from, List: cfg.CurrentList(), Todos: len(todo.Live(todoList.Todos))}, nil
+}
+
+// backupBasePath returns where the copy of the current list last uploaded
+// to or downloaded from the backups is kept
+func backupBasePath(cfg *config.Config) (string, error) {
+	kind, err := cfg.BackupType()
+	if err != nil {
+		return "", err
+	}
+	b := cfg.Backup
+	switch kind {
+	case config.BackupDrive:
+		return cfg.SyncBasePath("gdrive:" + cfg.Drive.Folder)
+	case config.BackupWebDAV:
+		return cfg.SyncBasePath("webdav:" + b.URL)
+	case config.BackupS3:
+		return cfg.SyncBasePath("s3:" + b.URL + "/" + b.Bucket + "/" + b.Path)
+	}
+	dir, err := cfg.BackupDir()
+	if err != nil {
+		return "", err
+	}
+	return cfg.SyncBasePath(kind + ":" + dir)
+}
+
+// backupTarget names where backups are kept, for progress messages
+func backupTarget(cfg *config.Config) string {
+	switch cfg.Backup.Type {
+	case "", config.BackupDrive:
+		return "Google Drive"
+	case config.BackupWebDAV:
+		return "the WebDAV server"
+	case config.BackupS3:
+		return "S3"
+	case config.BackupDir:
+		return "the backup directory"
+	case config.BackupGit:
+		return "the backup repository"
+	}
+	return "the backups"
+}
+
+// withBackups runs op against where backup.type says backups are kept.
+// Google Drive goes through withDrive, signing in as needed.
+func withBackups(cfg *config.Config, op func(remote backup.Remote) error) error {
+	kind, err := cfg.BackupType()
+	if err != nil {
+		return err
+	}
+	if kind == config.BackupDrive {
+		return withDrive(cfg, func(service *drive.Service) error {
+			backups, err := gdrive.OpenBackups(service, cfg.Drive.Folder)
+			if err != nil {
+				return err
+			}
+			return op(backups)
+		})
+	}
+	remote, err := openBackupRemote(cfg, kind)
+	if err != nil {
+		return err
+	}
+	return op(remote)
+}
+
+// openBackupRemote returns the remote for the backup types other than
+// Google Drive
+func openBackupRemote(cfg *config.Config, kind string) (backup.Remote, error) {
+	b := cfg.Backup
+	switch kind {
+	case config.BackupDir, config.BackupGit:
+		dir, err := cfg.BackupDir()
+		if err != nil {
+			return nil, err
+		}
+		if kind == config.BackupDir {
+			return backup.Dir{Path: dir}, nil
+		}
+		g, err := backup.OpenGit(dir, b.Push)
+		if err != nil {
+			return nil, fmt.Errorf("opening backup repository: %w", err)
+		}
+		return g, nil
+
+	case config.BackupWebDAV:
+		if b.URL == "" {
+			return nil, errors.New("backup.url is not set: give the WebDAV collection to keep backups in")
+		}
+		if err := validateServerURL(b.URL); err != nil {
+			return nil, fmt.Errorf("backup.url: %w", err)
+		}
+		w := &backup.WebDAV{URL: b.URL, User: b.User}
+		if b.User != "" {
+			password, err := readBackupSecret(b, fmt.Sprintf("password for %s at %s", b.User, b.URL))
+			if err != nil {
+				return nil, err
+			}
+			w.Password = password
+		}
+		return w, nil
+
+	case config.BackupS3:
+		if b.Bucket == "" {
+			return nil, errors.New("backup.bucket is not set: give the S3 bucket to keep backups in")
+		}
+		region := b.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		endpoint := b.URL
+		if endpoint == "" {
+			endpoint = "https://s3." + region + ".amazonaws.com"
+		} else if err := validateServerURL(endpoint); err != nil {
+			return nil, fmt.Errorf("backup.url: %w", err)
+		}
+		accessKey := b.User
+		if accessKey == "" {
+			accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		}
+		if accessKey == "" {
+			return nil, errors.New("no S3 access key ID: set backup.user or AWS_ACCESS_KEY_ID")
+		}
+		secretKey, err := readBackupSecret(b, "secret key for "+accessKey)
+		if err != nil {
+			return nil, err
+		}
+		return &backup.S3{Endpoint: endpoint, Bucket: b.Bucket, Region: region, Prefix: b.Path, AccessKey: accessKey, SecretKey: secretKey}, nil
+	}
+	return nil, fmt.Errorf("backup.type %q is not supported", kind)
+}
+
+// readBackupSecret finds the WebDAV password or S3 secret key, asking for
+// it on a terminal when the config has no other source
+func readBackupSecret(b config.BackupConfig, what string) (string, error) {
+	if secret, err := b.Secret(); secret != "" || err != nil {
+		return secret, err
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return readPassword(fmt.Sprintf("%s%s: ", strings.ToUpper(what[:1]), what[1:]))
+	}
+	return "", fmt.Errorf("no %s: set %s, or give backup a password_env or password_command", what, config.BackupSecretEnv)
+}
+
+// legacyBackups are remotes that may hold the single backup older
+// versions kept
+type legacyBackups interface {
+	Legacy() ([]byte, error)
+}
+
+// fetchBackup downloads the newest backup of the current list taken no
+// later than at, or the newest of all if at is zero, and describes where
+// it came from. Without snapshots, the newest is the single backup older
+// versions uploaded to Google Drive, if there is one. A list with no
+// backup to restore gives errNoBackup.
+func fetchBackup(cfg *config.Config, at time.Time) (*todo.TodoList, string, error) {
+	list := cfg.CurrentList()
+	var data []byte
+	var from string
+	err := withBackups(cfg, func(remote backup.Remote) error {
+		all, err := remote.List(list)
+		if err != nil {
+			return fmt.Errorf("failed to list backups: %w", err)
+		}
+
+		if len(all) == 0 && at.IsZero() {
+			legacy, ok := remote.(legacyBackups)
+			if !ok {
+				return errNoBackup
+			}
+			if data, err = legacy.Legacy(); err != nil {
+				return err
+			}
+			if data == nil {
+				return errNoBackup
+			}
+			from = fmt.Sprintf("'%s'", gdrive.LegacyFile)
+			return nil
+		}
+
+		if at.IsZero() {
+			at = time.Now()
+		}
+		v, ok := backup.Latest(all, at)
+		if !ok {
+			if len(all) == 0 {
+				return fmt.Errorf("%w of list %s in %s", errNoBackup, list, remote)
+			}
+			oldest := all[len(all)-1]
+			return fmt.Errorf("%w of list %s from before %s; the oldest is from %s",
+				errNoBackup, list, at.Local().Format("2006-01-02 15:04"), oldest.Time.Local().Format("2006-01-02 15:04"))
+		}
+		if data, err = remote.Get(v); err != nil {
+			return fmt.Errorf("failed to download backup: %w", err)
+		}
+		from = "the backup of " + v.Time.Local().Format("2006-01-02 15:04:05")
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Parse JSON
+	var todoList todo.TodoList
+	if err := json.Unmarshal(data, &todoList); err != nil {
+		return nil, "", fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return &todoList, from, nil
+}
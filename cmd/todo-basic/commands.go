@@ -0,0 +1,886 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"todo-bubbletea/client"
+	"todo-bubbletea/config"
+	"todo-bubbletea/merge"
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+// dueLayout is the date format accepted by --due
+const dueLayout = "2006-01-02"
+
+// refHelp explains the <id> argument of the commands that act on one todo
+const refHelp = "<id> is the todo's number, its UUID, or enough of the UUID to pick out one todo.\n" +
+	"A number is always taken as the todo's number."
+
+// priorities lists the values accepted by --priority
+var priorities = []string{"low", "medium", "high"}
+
+// app holds the state shared by every command: the global flags and the
+// configuration and store they select, opened on first use
+type app struct {
+	file string
+	list string
+
+	cfg *config.Config
+	st  store.Store
+
+	// warnLegacy is set when a command runs, rather than shell completion,
+	// so hints are only printed where the user can see them
+	warnLegacy bool
+}
+
+// config loads the configuration and applies --file and --list
+func (a *app) config() (*config.Config, error) {
+	if a.cfg != nil {
+		return a.cfg, nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	if a.file != "" {
+		if err := cfg.SetFile(a.file); err != nil {
+			return nil, fmt.Errorf("invalid --file: %w", err)
+		}
+	}
+	if a.list != "" {
+		if a.list != config.DefaultList {
+			if err := config.ValidateListName(a.list); err != nil {
+				return nil, fmt.Errorf("invalid --list: %w", err)
+			}
+		}
+		cfg.List = a.list
+	}
+
+	a.cfg = cfg
+	return cfg, nil
+}
+
+// store opens the current list
+func (a *app) store() (store.Store, error) {
+	if a.st != nil {
+		return a.st, nil
+	}
+
+	cfg, err := a.config()
+	if err != nil {
+		return nil, err
+	}
+	if a.warnLegacy {
+		if cwd, err := os.Getwd(); err == nil {
+			if legacy := cfg.LegacyDataFile(cwd); legacy != "" {
+				printWarning(fmt.Sprintf("Found %s from an older version; run 'todo move-data' to move it to the data directory", legacy))
+			}
+		}
+	}
+
+	st, _, err := cfg.OpenStore()
+	if err != nil {
+		return nil, fmt.Errorf("opening todos: %w", err)
+	}
+	a.st = st
+	return st, nil
+}
+
+func (a *app) close() {
+	if a.st != nil {
+		a.st.Close()
+		a.st = nil
+	}
+}
+
+// withStore adapts a command body that needs the store to cobra's RunE
+func (a *app) withStore(run func(st store.Store, args []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		st, err := a.store()
+		if err != nil {
+			return err
+		}
+		return run(st, args)
+	}
+}
+
+// newRootCmd builds the todo command on a, which the caller closes once
+// the command has run, whether or not it failed
+func newRootCmd(a *app) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "todo",
+		Short: "A command-line todo manager",
+		Long: "todo keeps a list of todos in a local store, syncs it with a todo server\n" +
+			"and backs it up to Google Drive, WebDAV, S3, a directory or a git repository.",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			a.warnLegacy = true
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			showHelp()
+		},
+	}
+	root.PersistentFlags().StringVarP(&a.file, "file", "f", "", "todo data file (overrides $"+config.FileEnv+" and the config file)")
+	root.PersistentFlags().StringVar(&a.list, "list", "", "named list to use")
+	root.RegisterFlagCompletionFunc("list", a.completeLists)
+
+	root.AddCommand(
+		newAddCmd(a),
+		newListCmd(a),
+		newCompleteCmd(a),
+		newDeleteCmd(a),
+		newEditCmd(a),
+		newListsCmd(a),
+		newMoveCmd(a),
+		newGCCmd(a),
+		newMigrateStoreCmd(a),
+		newSaveCmd(a),
+		newLoadCmd(a),
+		newSyncCmd(a),
+		newRemoteCmd(a),
+		newServeCmd(a),
+		newUploadCmd(a),
+		newDownloadCmd(a),
+		newBackupCmd(a),
+		newRestoreCmd(a),
+		newDriveCmd(a),
+		newMoveDataCmd(),
+	)
+
+	return root
+}
+
+func newAddCmd(a *app) *cobra.Command {
+	var item todo.Todo
+	var due string
+	var out output
+
+	cmd := &cobra.Command{
+		Use:     "add <title> [description]",
+		Aliases: []string{"a"},
+		Short:   "Add a new todo",
+		Example: `  todo add "Buy groceries" "Get milk and bread"
+  todo add "File taxes" --priority high --category home --due 2026-04-15`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			item.Title = args[0]
+			if len(args) > 1 {
+				if item.Description != "" {
+					return fmt.Errorf("give the description either as an argument or with --desc, not both")
+				}
+				item.Description = strings.Join(args[1:], " ")
+			}
+			if err := checkPriority(item.Priority); err != nil {
+				return err
+			}
+			dueDate, err := parseDue(due)
+			if err != nil {
+				return err
+			}
+			item.DueDate = dueDate
+
+			added, err := addTodo(st, item)
+			if err != nil {
+				return err
+			}
+			return out.todo(added, func() {
+				printSuccess(fmt.Sprintf("Added todo #%d: %s", added.ID, added.Title))
+			})
+		}),
+	}
+	out.register(cmd)
+	cmd.Flags().StringVar(&item.Description, "desc", "", "description")
+	cmd.Flags().StringVarP(&item.Priority, "priority", "p", "low", "priority: low, medium or high")
+	cmd.Flags().StringVarP(&item.Category, "category", "c", "", "category")
+	cmd.Flags().StringVar(&due, "due", "", "due date (YYYY-MM-DD)")
+	registerTodoFlagCompletion(a, cmd)
+	return cmd
+}
+
+func newListCmd(a *app) *cobra.Command {
+	var out output
+	var order string
+	var limit int
+	var reverse bool
+
+	cmd := &cobra.Command{
+		Use:     "list [query]",
+		Aliases: []string{"l", "ls"},
+		Short:   "List todos, optionally filtered by a query",
+		Long: `List shows every todo, or those matching a query made of these terms:
+
+  status:pending        pending, done or all
+  priority:high         also priority>=medium, priority<high, ...
+  category:work         category:none for todos without one
+  due<2026-11-01        also due:, <=, > and >=; due:none and due:any
+  created>=2026-01-01   when the todo was added
+  id>10                 by ID
+  title:report          words in the title only
+  "text search"         anything else searches title, description and category
+
+Dates may also be today, tomorrow or yesterday. Prefix a term with - to
+negate it, and put -- before a query that starts with -. Quote the query
+so the shell leaves < and > alone.`,
+		Example: `  todo list 'status:pending priority:high due<2026-11-01'
+  todo list 'category:work "quarterly report"' --sort due --limit 5
+  todo list -- -category:work
+  todo list --output json
+  todo list --format '{{.ID}}: {{.Title}}'`,
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			query, err := todo.ParseQuery(strings.Join(args, " "))
+			if err != nil {
+				return fmt.Errorf("invalid query: %w", err)
+			}
+
+			todos, err := st.List()
+			if err != nil {
+				return fmt.Errorf("loading todos: %w", err)
+			}
+			todos = query.Filter(todos)
+			if err := todo.Sort(todos, order); err != nil {
+				return err
+			}
+			if reverse {
+				slices.Reverse(todos)
+			}
+			if limit > 0 && len(todos) > limit {
+				todos = todos[:limit]
+			}
+
+			return out.todos(todos, func() {
+				printTodoTable(todos, a.cfg.CurrentList())
+			})
+		}),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			fields := make([]string, len(todo.QueryFields))
+			for i, f := range todo.QueryFields {
+				fields[i] = f + ":"
+			}
+			return fields, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&order, "sort", todo.OrderID, "order: "+strings.Join(todo.Orders, ", "))
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "show at most this many todos")
+	cmd.Flags().BoolVarP(&reverse, "reverse", "r", false, "reverse the order")
+	cmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions(todo.Orders, cobra.ShellCompDirectiveNoFileComp))
+	out.register(cmd)
+	return cmd
+}
+
+func newCompleteCmd(a *app) *cobra.Command {
+	var out output
+
+	cmd := &cobra.Command{
+		Use:     "complete <id>",
+		Aliases: []string{"c", "done"},
+		Short:   "Mark a todo as completed",
+		Long:    "Mark a todo as completed.\n\n" + refHelp,
+		Args:    cobra.ExactArgs(1),
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			id, err := resolveID(st, args[0])
+			if err != nil {
+				return err
+			}
+			completed, alreadyDone, err := completeTodo(st, id)
+			if err != nil {
+				return err
+			}
+			return out.todo(completed, func() {
+				if alreadyDone {
+					printWarning(fmt.Sprintf("Todo #%d is already completed", id))
+					return
+				}
+				printSuccess(fmt.Sprintf("Completed todo #%d: %s", id, completed.Title))
+			})
+		}),
+		ValidArgsFunction: a.completeIDs(func(t todo.Todo) bool { return !t.Completed }),
+	}
+	out.register(cmd)
+	return cmd
+}
+
+func newDeleteCmd(a *app) *cobra.Command {
+	var out output
+
+	cmd := &cobra.Command{
+		Use:     "delete <id>",
+		Aliases: []string{"d", "rm"},
+		Short:   "Delete a todo",
+		Long:    "Delete a todo.\n\n" + refHelp,
+		Args:    cobra.ExactArgs(1),
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			id, err := resolveID(st, args[0])
+			if err != nil {
+				return err
+			}
+			deleted, err := deleteTodo(st, id)
+			if err != nil {
+				return err
+			}
+			return out.todo(deleted, func() {
+				printSuccess(fmt.Sprintf("Deleted todo #%d: %s", id, deleted.Title))
+			})
+		}),
+		ValidArgsFunction: a.completeIDs(nil),
+	}
+	out.register(cmd)
+	return cmd
+}
+
+func newEditCmd(a *app) *cobra.Command {
+	var desc, priority, category, due string
+	var out output
+
+	cmd := &cobra.Command{
+		Use:     "edit <id> [title] [description]",
+		Aliases: []string{"e"},
+		Short:   "Edit a todo",
+		Long: "Edit changes the fields that are given and leaves the rest as they are.\n" +
+			"Use --due none to clear the due date.\n\n" + refHelp,
+		Example: `  todo edit 3 "Buy groceries and snacks"
+  todo edit 3 --priority high --due 2026-11-01`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var changes todoChanges
+			flags := cmd.Flags()
+			if len(args) > 1 {
+				changes.Title = &args[1]
+			}
+			if len(args) > 2 {
+				if flags.Changed("desc") {
+					return fmt.Errorf("give the description either as an argument or with --desc, not both")
+				}
+				joined := strings.Join(args[2:], " ")
+				changes.Description = &joined
+			}
+			if flags.Changed("desc") {
+				changes.Description = &desc
+			}
+			if flags.Changed("priority") {
+				if err := checkPriority(priority); err != nil {
+					return err
+				}
+				changes.Priority = &priority
+			}
+			if flags.Changed("category") {
+				changes.Category = &category
+			}
+			if flags.Changed("due") {
+				dueDate, err := parseDue(due)
+				if err != nil {
+					return err
+				}
+				changes.DueDate = dueDate
+				changes.SetDue = true
+			}
+			if changes == (todoChanges{}) {
+				return fmt.Errorf("nothing to change: give a new title or at least one flag")
+			}
+
+			st, err := a.store()
+			if err != nil {
+				return err
+			}
+			id, err := resolveID(st, args[0])
+			if err != nil {
+				return err
+			}
+			edited, oldTitle, err := editTodo(st, id, changes)
+			if err != nil {
+				return err
+			}
+			return out.todo(edited, func() {
+				if edited.Title == oldTitle {
+					printSuccess(fmt.Sprintf("Updated todo #%d: %s", id, edited.Title))
+					return
+				}
+				printSuccess(fmt.Sprintf("Updated todo #%d: %s → %s", id, oldTitle, edited.Title))
+			})
+		},
+		ValidArgsFunction: a.completeIDs(nil),
+	}
+	cmd.Flags().StringVar(&desc, "desc", "", "new description")
+	cmd.Flags().StringVarP(&priority, "priority", "p", "", "new priority: low, medium or high")
+	cmd.Flags().StringVarP(&category, "category", "c", "", "new category")
+	cmd.Flags().StringVar(&due, "due", "", "new due date (YYYY-MM-DD, or none)")
+	registerTodoFlagCompletion(a, cmd)
+	out.register(cmd)
+	return cmd
+}
+
+func newListsCmd(a *app) *cobra.Command {
+	var out output
+
+	cmd := &cobra.Command{
+		Use:   "lists",
+		Short: "Show all named lists",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := a.config()
+			if err != nil {
+				return err
+			}
+			summaries, err := listSummaries(cfg)
+			if err != nil {
+				return err
+			}
+			return out.lists(summaries, func() {
+				printLists(summaries)
+			})
+		},
+	}
+	out.register(cmd)
+	return cmd
+}
+
+func newMoveCmd(a *app) *cobra.Command {
+	var out output
+
+	cmd := &cobra.Command{
+		Use:     "move <id> <list>",
+		Aliases: []string{"mv"},
+		Short:   "Move a todo to another list",
+		Long:    "Move a todo to another list, where it gets a new number and UUID.\n\n" + refHelp,
+		Args:    cobra.ExactArgs(2),
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			id, err := resolveID(st, args[0])
+			if err != nil {
+				return err
+			}
+			moved, err := moveTodo(a.cfg, st, id, args[1])
+			if err != nil {
+				return err
+			}
+			return out.todo(moved, func() {
+				printSuccess(fmt.Sprintf("Moved todo #%d to list %s as #%d: %s", id, args[1], moved.ID, moved.Title))
+			})
+		}),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return a.completeIDs(nil)(cmd, args, toComplete)
+			case 1:
+				return a.completeLists(cmd, args, toComplete)
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	out.register(cmd)
+	return cmd
+}
+
+// gcResult describes what 'todo gc' purged
+type gcResult struct {
+	Purged int `json:"purged" yaml:"purged"`
+	// Before is when the purged todos were deleted by at the latest
+	Before time.Time `json:"before" yaml:"before"`
+}
+
+var gcColumns = []string{"purged", "before"}
+
+func gcRow(r gcResult) []string {
+	return []string{strconv.Itoa(r.Purged), r.Before.Format(time.RFC3339)}
+}
+
+func newGCCmd(a *app) *cobra.Command {
+	var olderThan string
+	var out output
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Purge deleted todos kept for sync",
+		Long: "Deleted todos are kept as tombstones so that sync can pass the deletion\n" +
+			"on to other machines. gc removes those deleted longer ago than --older-than,\n" +
+			"which defaults to sync.tombstone_age in the config file, or " + config.DefaultTombstoneAge + ".\n" +
+			"A machine that has not synced since then may bring them back.",
+		Args: cobra.NoArgs,
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			age, err := a.cfg.TombstoneAge()
+			if olderThan != "" {
+				age, err = config.ParseAge(olderThan)
+			}
+			if err != nil {
+				return err
+			}
+
+			before := time.Now().Add(-age)
+			purged, err := store.PurgeDeleted(st, before)
+			if err != nil {
+				return err
+			}
+			return out.gc(gcResult{Purged: purged, Before: before}, func() {
+				printSuccess(fmt.Sprintf("Purged %d deleted todos", purged))
+			})
+		}),
+	}
+	out.register(cmd)
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "purge todos deleted longer ago than this, e.g. 30d or 72h")
+	cmd.RegisterFlagCompletionFunc("older-than", cobra.NoFileCompletions)
+	return cmd
+}
+
+func newMigrateStoreCmd(a *app) *cobra.Command {
+	var out output
+
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("migrate-store <%s> [path]", strings.Join(store.Backends, "|")),
+		Short: "Copy todos to a json, sqlite or bolt store",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			path := ""
+			if len(args) > 1 {
+				path = args[1]
+			}
+			result, err := migrateStore(st, args[0], path, &out)
+			if err != nil {
+				return err
+			}
+			return out.migrate(result, result.print)
+		}),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return store.Backends, cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+	}
+	out.register(cmd)
+	return cmd
+}
+
+// newNetworkCmd builds save, load and sync, which share their arguments
+// and report what they did through out
+func newNetworkCmd(a *app, out *output, name, alias, short string, run func(st store.Store, nc client.Config) (networkResult, error)) *cobra.Command {
+	var flags networkFlags
+
+	cmd := &cobra.Command{
+		Use:   name + " [remote|server_url]",
+		Short: short,
+		Long: short + ". It takes the name of a remote added with 'todo remote add', or a\n" +
+			"server URL; without either it uses the default remote, or " + defaultServerURL + ".",
+		Args: cobra.MaximumNArgs(1),
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			nc, err := a.networkConfig(args, flags)
+			if err != nil {
+				return err
+			}
+			if out.machine() {
+				// Keep stdout for the result
+				nc.Notify = printWarning
+			}
+			result, err := run(st, nc)
+			if err != nil {
+				return err
+			}
+			return out.network(result, result.print)
+		}),
+		ValidArgsFunction: a.completeRemotes,
+	}
+	out.register(cmd)
+	if alias != "" {
+		cmd.Aliases = []string{alias}
+	}
+	cmd.Flags().StringVarP(&flags.user, "user", "u", "", "username for basic auth; the password is asked for")
+	cmd.Flags().StringVar(&flags.password, "password", "", "password for basic auth")
+	cmd.Flags().MarkDeprecated("password", "it leaves the password in shell history; answer the prompt, or add a remote that reads it from "+config.PasswordEnv+", a password_env or a password_command")
+	registerTLSFlags(cmd, &flags.tls)
+	return cmd
+}
+
+func newLoadCmd(a *app) *cobra.Command {
+	var out output
+
+	return newNetworkCmd(a, &out, "load", "ld", "Load todos from a todo server", func(st store.Store, nc client.Config) (networkResult, error) {
+		return loadFromNetwork(a.cfg, st, nc)
+	})
+}
+
+func newSaveCmd(a *app) *cobra.Command {
+	var force bool
+	var out output
+
+	cmd := newNetworkCmd(a, &out, "save", "s", "Save todos to a todo server", func(st store.Store, nc client.Config) (networkResult, error) {
+		result, err := saveToNetwork(a.cfg, st, nc, force)
+		if !errors.Is(err, client.ErrServerChanged) {
+			return result, err
+		}
+		err = fmt.Errorf("%w since they were last loaded or synced here", err)
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return networkResult{}, fmt.Errorf("%w; run 'todo sync' to merge them, or save --force to overwrite them", err)
+		}
+
+		printWarning(err.Error())
+		reader := bufio.NewReader(os.Stdin)
+		fmt.Fprintf(os.Stderr, "  Sync with them instead? [%sY%s/n] ", ColorCyan, ColorReset)
+		answer, _ := reader.ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "" && answer != "y" && answer != "yes" {
+			return networkResult{}, fmt.Errorf("nothing was saved")
+		}
+		out.progress("Syncing with network...")
+		return syncWithNetwork(a.cfg, st, nc, promptResolver(reader))
+	})
+	cmd.Long += `
+
+Save only replaces the server's todos if they are still the ones last
+loaded or synced here, so it never overwrites a teammate's changes
+unseen. If they have changed, save offers to sync instead; without a
+terminal it stops. Pass --force to overwrite them anyway.`
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite the server's todos even if they changed")
+	return cmd
+}
+
+func newSyncCmd(a *app) *cobra.Command {
+	var prefer string
+	var status bool
+	var out output
+
+	cmd := newNetworkCmd(a, &out, "sync", "", "Merge todos with a todo server", func(st store.Store, nc client.Config) (networkResult, error) {
+		var resolve merge.Resolver
+		switch {
+		case prefer != "":
+			side, err := merge.ParseSide(prefer)
+			if err != nil {
+				return networkResult{}, fmt.Errorf("invalid --prefer: %w", err)
+			}
+			resolve = merge.Prefer(side)
+		case term.IsTerminal(int(os.Stdin.Fd())):
+			resolve = promptResolver(os.Stdin)
+		}
+		out.progress("Syncing with network...")
+		return syncWithNetwork(a.cfg, st, nc, resolve)
+	})
+	cmd.Long += `
+
+Sync compares both sides with the copy saved after the last sync, so edits
+made on either side since then are kept, field by field. When both sides
+changed the same field, or one side deleted a todo the other edited, sync
+asks which to keep. Pass --prefer to decide without asking; without a
+terminal and without --prefer the conflicts are listed and nothing is
+written.
+
+Servers run with 'todo serve' only send and receive the todos changed
+since the last sync.
+
+Network failures are retried a few times, waiting longer each time. Changes
+made while the server is unreachable wait in the local todos and go with
+the next sync that gets through; --status lists them without contacting
+the server.`
+	// --status only reads the local files, so it must not ask for a password
+	sync := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if !status {
+			return sync(cmd, args)
+		}
+		return a.withStore(func(st store.Store, args []string) error {
+			name, remote, err := a.resolveRemote(args, networkFlags{})
+			if err != nil {
+				return err
+			}
+			report, err := readSyncStatus(a.cfg, st, name, remote.URL)
+			if err != nil {
+				return err
+			}
+			return out.status(report, func() {
+				printSyncStatus(report)
+			})
+		})(cmd, args)
+	}
+	cmd.Flags().BoolVar(&status, "status", false, "show the changes waiting to be synced, without syncing")
+	cmd.Flags().StringVar(&prefer, "prefer", "", "resolve conflicts in favour of local or remote")
+	cmd.RegisterFlagCompletionFunc("prefer", cobra.FixedCompletions([]string{"local", "remote"}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func newUploadCmd(a *app) *cobra.Command {
+	var out output
+
+	cmd := &cobra.Command{
+		Use:     "upload",
+		Aliases: []string{"up"},
+		Short:   "Back up todos",
+		Long: `Upload adds a snapshot of the current list to the backups, in Google
+Drive unless backup.type says otherwise, and deletes older snapshots the
+retention policy no longer covers; see 'todo help backup'.`,
+		Args: cobra.NoArgs,
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			result, err := uploadBackup(a.cfg, st, &out)
+			if err != nil {
+				return err
+			}
+			return out.upload(result, result.print)
+		}),
+	}
+	out.register(cmd)
+	return cmd
+}
+
+func newDownloadCmd(a *app) *cobra.Command {
+	var dryRun, force bool
+	var out output
+
+	cmd := &cobra.Command{
+		Use:     "download",
+		Aliases: []string{"down"},
+		Short:   "Merge the newest backup into the todos",
+		Long: `Download merges the newest backup into the current list, the way sync
+merges with a server: it compares both with the copy uploaded or
+downloaded last, so todos added, edited or deleted here since are kept
+alongside the changes in the backup.
+
+When the backup changed a field that was also changed here and not
+uploaded yet, download lists the conflicts and changes nothing. Pass
+--force to take the backup's side, or --dry-run to see what would change.`,
+		Example: `  todo download --dry-run
+  todo download --force`,
+		Args: cobra.NoArgs,
+		RunE: a.withStore(func(st store.Store, args []string) error {
+			result, err := downloadBackup(a.cfg, st, dryRun, force, &out)
+			if err != nil {
+				return err
+			}
+			return out.download(result, result.print)
+		}),
+	}
+	out.register(cmd)
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "show what would change without changing anything")
+	cmd.Flags().BoolVar(&force, "force", false, "take the backup's side where it conflicts with local changes")
+	return cmd
+}
+
+func newMoveDataCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "move-data",
+		Short: "Move todos.json and credentials from this directory to the data and config directories",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return moveData()
+		},
+	}
+}
+
+// registerTodoFlagCompletion completes the values of the flags shared by
+// add and edit
+func registerTodoFlagCompletion(a *app, cmd *cobra.Command) {
+	cmd.RegisterFlagCompletionFunc("priority", cobra.FixedCompletions(priorities, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("category", a.completeCategories)
+	cmd.RegisterFlagCompletionFunc("due", cobra.NoFileCompletions)
+	cmd.RegisterFlagCompletionFunc("desc", cobra.NoFileCompletions)
+}
+
+// completeIDs completes the first argument with the IDs of the todos that
+// match keep, or of every todo when keep is nil. Titles are shown as
+// descriptions by shells that support them.
+func (a *app) completeIDs(keep func(todo.Todo) bool) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		st, err := a.store()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		defer a.close()
+
+		todos, err := st.List()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var ids []string
+		for _, t := range todos {
+			if keep == nil || keep(t) {
+				ids = append(ids, fmt.Sprintf("%d\t%s", t.ID, t.Title))
+			}
+		}
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeCategories completes --category with the categories in use
+func (a *app) completeCategories(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	st, err := a.store()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer a.close()
+
+	todos, err := st.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	seen := make(map[string]bool)
+	var categories []string
+	for _, t := range todos {
+		if t.Category != "" && !seen[t.Category] {
+			seen[t.Category] = true
+			categories = append(categories, t.Category)
+		}
+	}
+	return categories, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLists completes list names
+func (a *app) completeLists(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := a.config()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	names, err := cfg.Lists()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// resolveID turns a todo reference from the command line into its ID. The
+// reference may be the ID, the UUID or a prefix of the UUID that only one
+// todo has.
+func resolveID(st store.Store, ref string) (int, error) {
+	t, err := store.Resolve(st, ref)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		if _, numErr := strconv.Atoi(ref); numErr == nil {
+			return 0, fmt.Errorf("todo #%s not found", ref)
+		}
+		return 0, fmt.Errorf("no todo matches %q", ref)
+	case errors.Is(err, store.ErrAmbiguous):
+		return 0, fmt.Errorf("%q matches more than one todo; give more of its UUID", ref)
+	case err != nil:
+		return 0, err
+	}
+	return t.ID, nil
+}
+
+func checkPriority(priority string) error {
+	for _, p := range priorities {
+		if priority == p {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid priority %q (want one of %s)", priority, strings.Join(priorities, ", "))
+}
+
+// parseDue parses --due. Empty and "none" mean no due date.
+func parseDue(value string) (*time.Time, error) {
+	if value == "" || value == "none" {
+		return nil, nil
+	}
+	due, err := time.Parse(dueLayout, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid due date %q: use YYYY-MM-DD", value)
+	}
+	return &due, nil
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+	"golang.org/x/term"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+
+	"todo-bubbletea/config"
+	"todo-bubbletea/gdrive"
+)
+
+// loginTimeout is how long 'todo drive login' waits for the browser
+const loginTimeout = 5 * time.Minute
+
+func newDriveCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drive",
+		Short: "Sign in to Google Drive for upload and download",
+		Long: `Drive manages access to the Google Drive used by upload and download.
+
+Login opens Google's sign-in page in the browser and waits for it to
+redirect back to a port on this machine, so there is no code to copy.
+It needs an OAuth client of the "Desktop app" type from the Google Cloud
+console, saved as the drive.credentials_file in the config directory.`,
+		Example: `  todo drive login
+  todo drive status
+  todo drive logout`,
+	}
+	cmd.AddCommand(newDriveLoginCmd(a), newDriveLogoutCmd(a), newDriveStatusCmd(a))
+	return cmd
+}
+
+func newDriveLoginCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "login",
+		Short: "Sign in to Google Drive in the browser",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := a.config()
+			if err != nil {
+				return err
+			}
+			conf, tokenFile, err := driveOAuth(cfg)
+			if err != nil {
+				return err
+			}
+			if _, err := driveLogin(conf, tokenFile); err != nil {
+				return err
+			}
+			printSuccess("Signed in to Google Drive")
+			return nil
+		},
+	}
+}
+
+func newDriveLogoutCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Forget the saved Google Drive sign-in",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := a.config()
+			if err != nil {
+				return err
+			}
+			tokenFile, err := cfg.TokenFile()
+			if err != nil {
+				return err
+			}
+			ok, err := gdrive.Logout(tokenFile)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				printInfo("Not signed in to Google Drive")
+				return nil
+			}
+			printSuccess("Signed out of Google Drive")
+			printInfo("To revoke access entirely, remove the app at https://myaccount.google.com/permissions")
+			return nil
+		},
+	}
+}
+
+func newDriveStatusCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether Google Drive is signed in",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := a.config()
+			if err != nil {
+				return err
+			}
+			return printDriveStatus(cfg)
+		},
+	}
+}
+
+// printDriveStatus shows the OAuth client and saved token, and the account
+// they belong to if Google can be asked
+func printDriveStatus(cfg *config.Config) error {
+	credentialsFile, err := cfg.CredentialsFile()
+	if err != nil {
+		return err
+	}
+	tokenFile, err := cfg.TokenFile()
+	if err != nil {
+		return err
+	}
+
+	client := credentialsFile
+	if _, err := os.Stat(credentialsFile); os.IsNotExist(err) {
+		client += " (missing)"
+	}
+	tok, err := gdrive.LoadToken(tokenFile)
+	if err != nil && !errors.Is(err, gdrive.ErrNotLoggedIn) {
+		return err
+	}
+
+	fmt.Printf("%s%s☁️  Google Drive%s\n", ColorYellow, ColorBold, ColorReset)
+	fmt.Println()
+	fmt.Printf("   %-14s %s\n", "OAuth client", client)
+	if tok == nil {
+		fmt.Printf("   %-14s %s\n", "Signed in", "no; run 'todo drive login'")
+		fmt.Println()
+		return nil
+	}
+	fmt.Printf("   %-14s %s\n", "Token", tokenFile)
+	expiry := "never"
+	if !tok.Expiry.IsZero() {
+		expiry = tok.Expiry.Local().Format("2006-01-02 15:04:05")
+		if tok.Expiry.Before(time.Now()) {
+			expiry += " (expired)"
+		}
+	}
+	fmt.Printf("   %-14s %s\n", "Access expires", expiry)
+	refresh := "yes"
+	if tok.RefreshToken == "" {
+		refresh = "no; sign in again when access expires"
+	}
+	fmt.Printf("   %-14s %s\n", "Refreshable", refresh)
+
+	account := "unknown"
+	if service, err := driveService(cfg); err != nil {
+		account += fmt.Sprintf(" %s(%v)%s", ColorDim, err, ColorReset)
+	} else if about, err := service.About.Get().Fields("user(displayName,emailAddress)").Do(); errors.Is(err, gdrive.ErrSignInExpired) {
+		account = "sign-in expired; run 'todo drive login'"
+	} else if err != nil {
+		account += fmt.Sprintf(" %s(%v)%s", ColorDim, err, ColorReset)
+	} else if about.User != nil {
+		account = fmt.Sprintf("%s <%s>", about.User.DisplayName, about.User.EmailAddress)
+	}
+	fmt.Printf("   %-14s %s\n", "Account", account)
+	fmt.Println()
+	return nil
+}
+
+// driveOAuth returns the OAuth client for Google Drive and the file its
+// token is kept in
+func driveOAuth(cfg *config.Config) (*oauth2.Config, string, error) {
+	credentialsFile, err := cfg.CredentialsFile()
+	if err != nil {
+		return nil, "", err
+	}
+	tokenFile, err := cfg.TokenFile()
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := os.Stat(credentialsFile); os.IsNotExist(err) {
+		printInfo("To get credentials:")
+		printInfo("1. Go to Google Cloud Console")
+		printInfo("2. Create a new project or select existing one")
+		printInfo("3. Enable Google Drive API")
+		printInfo("4. Create credentials (OAuth 2.0 Client ID of the Desktop app type)")
+		printInfo(fmt.Sprintf("5. Download JSON and save as %s", credentialsFile))
+	}
+	conf, err := gdrive.OAuthConfig(credentialsFile)
+	if err != nil {
+		return nil, "", err
+	}
+	return conf, tokenFile, nil
+}
+
+// driveLogin signs in through the browser and saves the token
+func driveLogin(conf *oauth2.Config, tokenFile string) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), loginTimeout)
+	defer cancel()
+
+	tok, err := gdrive.Login(ctx, conf, func(authURL string) {
+		printInfo("Sign in to Google Drive in your browser. If it does not open, visit:")
+		fmt.Println(authURL)
+		openBrowser(authURL)
+		printProgress("Waiting for the browser...")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := gdrive.SaveToken(tokenFile, tok); err != nil {
+		return nil, fmt.Errorf("saving Google Drive token: %w", err)
+	}
+	return tok, nil
+}
+
+// openBrowser tries to show url in the desktop's browser, leaving the
+// user to open it by hand if that fails
+func openBrowser(url string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if cmd.Start() == nil {
+		go cmd.Wait()
+	}
+}
+
+// driveService connects to Google Drive with the saved token, returning
+// gdrive.ErrNotLoggedIn if there is none
+func driveService(cfg *config.Config) (*drive.Service, error) {
+	conf, tokenFile, err := driveOAuth(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := gdrive.LoadToken(tokenFile)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	return drive.NewService(ctx, option.WithHTTPClient(gdrive.Client(ctx, conf, tok, tokenFile)))
+}
+
+// getGoogleDriveService connects to Google Drive, signing in first on a
+// terminal if there is no saved token
+func getGoogleDriveService(cfg *config.Config) (*drive.Service, error) {
+	service, err := driveService(cfg)
+	if !errors.Is(err, gdrive.ErrNotLoggedIn) {
+		return service, err
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("%w; run 'todo drive login'", err)
+	}
+
+	conf, tokenFile, err := driveOAuth(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := driveLogin(conf, tokenFile); err != nil {
+		return nil, err
+	}
+	return driveService(cfg)
+}
+
+// withDrive runs op against Google Drive. When the saved sign-in can no
+// longer be refreshed it offers to sign in again on a terminal, and then
+// runs op once more.
+func withDrive(cfg *config.Config, op func(service *drive.Service) error) error {
+	service, err := getGoogleDriveService(cfg)
+	if err != nil {
+		return err
+	}
+	err = op(service)
+	if !errors.Is(err, gdrive.ErrSignInExpired) {
+		return err
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("%w; run 'todo drive login'", err)
+	}
+
+	printWarning("Your Google Drive sign-in has expired or been revoked")
+	fmt.Printf("  Sign in again? [%sY%s/n] ", ColorCyan, ColorReset)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "" && answer != "y" && answer != "yes" {
+		return err
+	}
+	conf, tokenFile, err := driveOAuth(cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := driveLogin(conf, tokenFile); err != nil {
+		return err
+	}
+	if service, err = driveService(cfg); err != nil {
+		return err
+	}
+	return op(service)
+}
@@ -0,0 +1,976 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"todo-bubbletea/client"
+	"todo-bubbletea/config"
+	"todo-bubbletea/merge"
+	"todo-bubbletea/outbox"
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+// Network configuration
+const (
+	defaultServerURL = "http://localhost:8080"
+	apiEndpoint      = "/api/todos"
+)
+
+// Color codes for terminal output, cleared by disableColors
+var (
+	ColorReset  = "\033[0m"
+	ColorRed    = "\033[31m"
+	ColorGreen  = "\033[32m"
+	ColorYellow = "\033[33m"
+	ColorBlue   = "\033[34m"
+	ColorPurple = "\033[35m"
+	ColorCyan   = "\033[36m"
+	ColorWhite  = "\033[37m"
+	ColorBold   = "\033[1m"
+	ColorDim    = "\033[2m"
+	ColorItalic = "\033[3m"
+
+	// Background colors
+	BgRed    = "\033[41m"
+	BgGreen  = "\033[42m"
+	BgYellow = "\033[43m"
+	BgBlue   = "\033[44m"
+	BgPurple = "\033[45m"
+	BgCyan   = "\033[46m"
+	BgWhite  = "\033[47m"
+)
+
+// disableColors turns every color code into an empty string
+func disableColors() {
+	for _, c := range []*string{
+		&ColorReset, &ColorRed, &ColorGreen, &ColorYellow, &ColorBlue, &ColorPurple, &ColorCyan, &ColorWhite,
+		&ColorBold, &ColorDim, &ColorItalic,
+		&BgRed, &BgGreen, &BgYellow, &BgBlue, &BgPurple, &BgCyan, &BgWhite,
+	} {
+		*c = ""
+	}
+}
+
+// Utility functions for beautiful formatting
+func printHeader() {
+	fmt.Printf("%s%s╔══════════════════════════════════════════════════════════════════════════════╗%s\n", ColorCyan, ColorBold, ColorReset)
+	fmt.Printf("%s%s║%s %s%sTODO CLI%s %s- A Beautiful Command-Line Todo Manager%s %s║%s\n", ColorCyan, ColorBold, ColorReset, ColorYellow, ColorBold, ColorReset, ColorDim, ColorCyan, ColorBold, ColorReset)
+	fmt.Printf("%s%s╚══════════════════════════════════════════════════════════════════════════════╝%s\n", ColorCyan, ColorBold, ColorReset)
+	fmt.Println()
+}
+
+func printSuccess(message string) {
+	fmt.Printf("%s✅ %s%s%s\n", ColorGreen, ColorBold, message, ColorReset)
+}
+
+// printError and printWarning write to stderr so they never mix with
+// results piped to another program
+func printError(message string) {
+	fmt.Fprintf(os.Stderr, "%s❌ %s%s%s\n", ColorRed, ColorBold, message, ColorReset)
+}
+
+func printWarning(message string) {
+	fmt.Fprintf(os.Stderr, "%s⚠️  %s%s%s\n", ColorYellow, ColorBold, message, ColorReset)
+}
+
+func printInfo(message string) {
+	fmt.Printf("%sℹ️  %s%s%s\n", ColorBlue, ColorBold, message, ColorReset)
+}
+
+func printProgress(message string) {
+	fmt.Printf("%s🔄 %s%s%s\n", ColorCyan, ColorBold, message, ColorReset)
+}
+
+func centerText(text string, width int) string {
+	textLen := utf8.RuneCountInString(text)
+	if textLen >= width {
+		return text
+	}
+	padding := (width - textLen) / 2
+	return strings.Repeat(" ", padding) + text + strings.Repeat(" ", width-textLen-padding)
+}
+
+func printSeparator(char string, length int) {
+	fmt.Printf("%s%s%s\n", ColorDim, strings.Repeat(char, length), ColorReset)
+}
+
+func printBoxedText(text string, color string) {
+	lines := strings.Split(text, "\n")
+	maxWidth := 0
+	for _, line := range lines {
+		if utf8.RuneCountInString(line) > maxWidth {
+			maxWidth = utf8.RuneCountInString(line)
+		}
+	}
+
+	width := maxWidth + 4
+	fmt.Printf("%s┌%s┐%s\n", color, strings.Repeat("─", width-2), ColorReset)
+	for _, line := range lines {
+		padding := width - 2 - utf8.RuneCountInString(line)
+		fmt.Printf("%s│ %s%s │%s\n", color, line, strings.Repeat(" ", padding), ColorReset)
+	}
+	fmt.Printf("%s└%s┘%s\n", color, strings.Repeat("─", width-2), ColorReset)
+}
+
+func main() {
+	if !useColor() {
+		disableColors()
+	}
+	if err := execute(); err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+}
+
+// execute runs the command line, closing the store and releasing its lock
+// however the command ends
+func execute() error {
+	a := &app{}
+	defer a.close()
+	return newRootCmd(a).Execute()
+}
+
+func showHelp() {
+	printHeader()
+
+	fmt.Printf("%s%s📋 USAGE%s\n", ColorYellow, ColorBold, ColorReset)
+	fmt.Printf("  %stodo%s [--file <path>] [--list <name>] <command> [arguments] [flags]\n", ColorCyan, ColorReset)
+	fmt.Printf("  %sRun 'todo <command> --help' for the flags each command takes%s\n\n", ColorDim, ColorReset)
+
+	fmt.Printf("%s%s🎯 COMMANDS%s\n", ColorYellow, ColorBold, ColorReset)
+
+	// Local operations
+	fmt.Printf("  %s%s📝 Local Operations%s\n", ColorBlue, ColorBold, ColorReset)
+	fmt.Printf("    %sadd, a%s     %s<title> [description]%s    %sAdd a new todo (--priority, --category, --due, --desc)%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %slist, l%s    %s%s                     %sList all todos%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %scomplete, c%s %s<id>%s                  %sMark a todo as completed%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %sdelete, d%s   %s<id>%s                  %sDelete a todo%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %sedit, e%s     %s<id> [title] [desc]%s   %sEdit a todo (same flags as add)%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %slists%s      %s%s                     %sShow all named lists%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %smove, mv%s   %s<id> <list>%s           %sMove a todo to another list%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %smigrate-store%s %s<backend> [path]%s  %sCopy todos to a json, sqlite or bolt store%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Println()
+
+	// Network operations
+	fmt.Printf("  %s%s🌐 Network Operations%s\n", ColorPurple, ColorBold, ColorReset)
+	fmt.Printf("    %ssave, s%s    %s[remote|url] [--user]%s       %sSave todos to network%s\n", ColorCyan, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %sload, ld%s   %s[remote|url] [--user]%s       %sLoad todos from network%s\n", ColorCyan, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %ssync%s       %s[remote|url] [--user]%s       %sMerge with network (--prefer local|remote)%s\n", ColorCyan, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %ssync%s       %s--status%s                    %sShow changes waiting to be synced%s\n", ColorCyan, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %sremote%s     %sadd|list|remove%s       %sManage named todo servers%s\n", ColorCyan, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %sserve%s      %s[--addr :8080]%s        %sServe todos for save, load and sync%s\n", ColorCyan, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Println()
+
+	// Cloud operations
+	fmt.Printf("  %s%s☁️  Cloud Operations%s\n", ColorGreen, ColorBold, ColorReset)
+	fmt.Printf("    %supload, up%s  %s%s                     %sBack up todos (Google Drive by default)%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %sdownload, down%s %s[--dry-run]%s       %sMerge in the newest backup%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %sbackup list%s %s%s                     %sShow the current list's backups%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %srestore%s    %s[--at YYYY-MM-DD]%s     %sReplace todos with a backup from that date%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %sdrive%s      %slogin|logout|status%s   %sSign in to Google Drive in the browser%s\n", ColorGreen, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Println()
+
+	// Utility
+	fmt.Printf("  %s%s🔧 Utility%s\n", ColorYellow, ColorBold, ColorReset)
+	fmt.Printf("    %sgc%s         %s[--older-than 30d]%s    %sPurge deleted todos kept for sync%s\n", ColorWhite, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %smove-data%s  %s%s                     %sMove todos.json and credentials from this directory to the data/config dirs%s\n", ColorWhite, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %scompletion%s %s<bash|zsh|fish>%s        %sPrint a shell completion script%s\n", ColorWhite, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Printf("    %shelp%s       %s[command]%s             %sShow help for a command%s\n", ColorWhite, ColorReset, ColorDim, ColorReset, ColorItalic, ColorReset)
+	fmt.Println()
+
+	fmt.Printf("%s%s💡 EXAMPLES%s\n", ColorYellow, ColorBold, ColorReset)
+	examples := []string{
+		"todo add \"Buy groceries\" \"Get milk and bread\"",
+		"todo add \"File taxes\" --priority high --category home --due 2026-04-15",
+		"todo list",
+		"todo complete 1",
+		"todo list --list work",
+		"todo move 3 work",
+		"todo save http://localhost:8080",
+		"todo load https://todos.example.com --user alice",
+		"todo remote add team https://todos.example.com --user alice --password-command \"pass show todo/team\"",
+		"TODO_PASSWORD=... todo sync --user alice",
+		"todo sync team",
+		"todo drive login",
+		"todo upload",
+		"todo restore --at 2026-10-01",
+	}
+
+	for _, example := range examples {
+		fmt.Printf("  %s$ %s%s%s\n", ColorDim, ColorGreen, example, ColorReset)
+	}
+	fmt.Println()
+
+	fmt.Printf("%s%s🎨 Features%s\n", ColorYellow, ColorBold, ColorReset)
+	features := []string{
+		"✨ Beautiful table formatting with colors",
+		"💾 Local JSON storage",
+		"🌐 Network synchronization",
+		"📊 Progress indicators",
+		"🎯 Intuitive command structure",
+		"⚡ Fast and lightweight",
+	}
+
+	for _, feature := range features {
+		fmt.Printf("  %s%s\n", ColorDim, feature)
+	}
+	fmt.Println()
+}
+
+// moveData moves files that older versions kept in the working directory
+// into the data and config directories
+func moveData() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to read working directory: %w", err)
+	}
+
+	moves, err := config.LegacyMoves(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to locate data directories: %w", err)
+	}
+	if len(moves) == 0 {
+		printInfo("Nothing to move")
+		return nil
+	}
+
+	for _, m := range moves {
+		printProgress(fmt.Sprintf("Moving %s → %s", m.From, m.To))
+	}
+	if err := config.ApplyMoves(moves); err != nil {
+		return fmt.Errorf("failed to move files: %w", err)
+	}
+	printSuccess(fmt.Sprintf("Moved %d files", len(moves)))
+	return nil
+}
+
+// loadTodos reads the whole store, for commands that work on the full list
+func loadTodos(st store.Store) (*todo.TodoList, error) {
+	return store.Snapshot(st)
+}
+
+// saveTodos replaces the contents of the store with todoList
+func saveTodos(st store.Store, todoList *todo.TodoList) error {
+	return store.Replace(st, todoList)
+}
+
+// addTodo stores a new todo built from item, which supplies the title and
+// any optional fields, and returns it with its ID
+func addTodo(st store.Store, item todo.Todo) (todo.Todo, error) {
+	added := item
+	err := st.Update(func(tx store.Tx) error {
+		id, err := tx.NextID()
+		if err != nil {
+			return err
+		}
+		added.ID = id
+		added.UUID = todo.NewUUID()
+		added.Completed = false
+		added.CreatedAt = time.Now()
+		if added.Priority == "" {
+			added.Priority = "low"
+		}
+		return tx.Put(added)
+	})
+	if err != nil {
+		return todo.Todo{}, fmt.Errorf("saving todo: %w", err)
+	}
+	return added, nil
+}
+
+// todoError explains a failed change to todo #id
+func todoError(id int, err error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("todo #%d not found", id)
+	}
+	return fmt.Errorf("saving todo: %w", err)
+}
+
+// printTodoTable prints todos as a table with a summary line
+func printTodoTable(todos []todo.Todo, listName string) {
+	heading := "📝 Your Todos"
+	if listName != config.DefaultList {
+		heading = fmt.Sprintf("📝 Your Todos — %s", listName)
+	}
+
+	if len(todos) == 0 {
+		fmt.Printf("%s%s%s%s\n", ColorYellow, ColorBold, heading, ColorReset)
+		fmt.Println()
+		printBoxedText("No todos found. Add one with 'todo add <title>'", ColorYellow)
+		fmt.Println()
+		return
+	}
+
+	fmt.Printf("%s%s%s%s\n", ColorYellow, ColorBold, heading, ColorReset)
+	fmt.Println()
+
+	// Table header
+	fmt.Printf("%-3s %-2s %-30s %-30s %-8s %-12s %-10s %-10s %-16s\n", "ID", "ST", "TITLE", "DESCRIPTION", "PRIORITY", "CATEGORY", "DUE", "STATUS", "DATE")
+	fmt.Println(strings.Repeat("-", 133))
+
+	for _, todo := range todos {
+		status := "⏳"
+		statusText := "Pending"
+		if todo.Completed {
+			status = "✅"
+			statusText = "Completed"
+		}
+
+		due := ""
+		if todo.DueDate != nil {
+			due = todo.DueDate.Format("2006-01-02")
+		}
+
+		// Format date
+		timeStr := todo.CreatedAt.Format("2006-01-02 15:04")
+		if todo.Completed && todo.CompletedAt != nil {
+			timeStr = todo.CompletedAt.Format("2006-01-02 15:04")
+		}
+
+		fmt.Printf("%-3d %-2s %-30s %-30s %-8s %-12s %-10s %-10s %-16s\n",
+			todo.ID, status, truncate(todo.Title, 30), truncate(todo.Description, 30),
+			todo.Priority, truncate(todo.Category, 12), due, statusText, timeStr)
+
+		// Show description if it exists
+		// if todo.Description != "" {
+		// 	desc := todo.Description
+		// 	if len(desc) > 70 {
+		// 		desc = desc[:67] + "..."
+		// 	}
+		// 	fmt.Printf("     %s\n", desc)
+		// }
+	}
+
+	// Summary
+	completed := 0
+	for _, todo := range todos {
+		if todo.Completed {
+			completed++
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%s%s📊 Summary: %d total, %d completed, %d pending%s\n",
+		ColorDim, ColorBold, len(todos), completed, len(todos)-completed, ColorReset)
+	fmt.Println()
+}
+
+// truncate shortens s to at most n runes, marking the cut with "..."
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-3]) + "..."
+}
+
+// completeTodo marks a todo as completed. It also reports whether the todo
+// was already completed, in which case nothing is changed.
+func completeTodo(st store.Store, id int) (todo.Todo, bool, error) {
+	var completed todo.Todo
+	alreadyDone := false
+	err := st.Update(func(tx store.Tx) error {
+		todo, err := tx.Get(id)
+		if err != nil {
+			return err
+		}
+		completed = todo
+		if todo.Completed {
+			alreadyDone = true
+			return nil
+		}
+
+		now := time.Now()
+		completed.Completed = true
+		completed.CompletedAt = &now
+		return tx.Put(completed)
+	})
+	if err != nil {
+		return todo.Todo{}, false, todoError(id, err)
+	}
+	return completed, alreadyDone, nil
+}
+
+// deleteTodo removes a todo and returns what was removed
+func deleteTodo(st store.Store, id int) (todo.Todo, error) {
+	var deleted todo.Todo
+	err := st.Update(func(tx store.Tx) error {
+		var err error
+		deleted, err = tx.Get(id)
+		if err != nil {
+			return err
+		}
+		return tx.Delete(id)
+	})
+	if err != nil {
+		return todo.Todo{}, todoError(id, err)
+	}
+	return deleted, nil
+}
+
+// todoChanges holds the fields edit was asked to change; nil leaves a
+// field as it is
+type todoChanges struct {
+	Title       *string
+	Description *string
+	Priority    *string
+	Category    *string
+	// DueDate replaces the due date when SetDue is true; nil clears it
+	DueDate *time.Time
+	SetDue  bool
+}
+
+// editTodo applies changes to a todo and returns the result along with the
+// title it had before
+func editTodo(st store.Store, id int, changes todoChanges) (todo.Todo, string, error) {
+	var edited todo.Todo
+	var oldTitle string
+	err := st.Update(func(tx store.Tx) error {
+		todo, err := tx.Get(id)
+		if err != nil {
+			return err
+		}
+		oldTitle = todo.Title
+		if changes.Title != nil {
+			todo.Title = *changes.Title
+		}
+		if changes.Description != nil {
+			todo.Description = *changes.Description
+		}
+		if changes.Priority != nil {
+			todo.Priority = *changes.Priority
+		}
+		if changes.Category != nil {
+			todo.Category = *changes.Category
+		}
+		if changes.SetDue {
+			todo.DueDate = changes.DueDate
+		}
+		edited = todo
+		return tx.Put(todo)
+	})
+	if err != nil {
+		return todo.Todo{}, "", todoError(id, err)
+	}
+	return edited, oldTitle, nil
+}
+
+// migrateResult describes what 'todo migrate-store' copied
+type migrateResult struct {
+	Backend string `json:"backend" yaml:"backend"`
+	Path    string `json:"path" yaml:"path"`
+	Copied  int    `json:"copied" yaml:"copied"`
+}
+
+var migrateColumns = []string{"backend", "path", "copied"}
+
+func migrateRow(r migrateResult) []string {
+	return []string{r.Backend, r.Path, strconv.Itoa(r.Copied)}
+}
+
+// print tells people what was copied and how to start using it
+func (r migrateResult) print() {
+	printSuccess(fmt.Sprintf("Copied %d todos to %s", r.Copied, r.Path))
+	cfgFile, _ := config.File()
+	printInfo(fmt.Sprintf("To use it, set \"store\": {\"backend\": \"%s\", \"path\": \"%s\"} in %s", r.Backend, r.Path, cfgFile))
+}
+
+// migrateStore copies every todo from the current store into a new backend
+func migrateStore(st store.Store, backend, path string, out *output) (migrateResult, error) {
+	if path == "" {
+		dir, err := config.DataDir()
+		if err != nil {
+			return migrateResult{}, fmt.Errorf("failed to locate data directory: %w", err)
+		}
+		path = filepath.Join(dir, store.DefaultPath(backend))
+	}
+
+	dst, err := store.Open(backend, path)
+	if err != nil {
+		return migrateResult{}, fmt.Errorf("failed to open %s store: %w", backend, err)
+	}
+	defer dst.Close()
+
+	out.progress(fmt.Sprintf("Copying todos to %s store at %s...", backend, path))
+	n, err := store.Copy(dst, st)
+	if err != nil {
+		return migrateResult{}, fmt.Errorf("failed to migrate todos: %w", err)
+	}
+	return migrateResult{Backend: backend, Path: path, Copied: n}, nil
+}
+
+// listSummary describes one named list for 'todo lists'
+type listSummary struct {
+	Name    string `json:"name" yaml:"name"`
+	Current bool   `json:"current" yaml:"current"`
+	Total   int    `json:"total" yaml:"total"`
+	Pending int    `json:"pending" yaml:"pending"`
+	Path    string `json:"path" yaml:"path"`
+	// Exists is false until the list's first todo is added
+	Exists bool `json:"exists" yaml:"exists"`
+	// Error is set when the list's file could not be read
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+var listColumns = []string{"name", "current", "total", "pending", "path", "exists", "error"}
+
+func listRow(l listSummary) []string {
+	return []string{
+		l.Name,
+		strconv.FormatBool(l.Current),
+		strconv.Itoa(l.Total),
+		strconv.Itoa(l.Pending),
+		l.Path,
+		strconv.FormatBool(l.Exists),
+		l.Error,
+	}
+}
+
+// listSummaries counts the todos in every named list
+func listSummaries(cfg *config.Config) ([]listSummary, error) {
+	names, err := cfg.Lists()
+	if err != nil {
+		return nil, fmt.Errorf("finding lists: %w", err)
+	}
+
+	summaries := make([]listSummary, 0, len(names))
+	for _, name := range names {
+		path, _ := cfg.ListPath(name)
+		summary := listSummary{Name: name, Current: name == cfg.CurrentList(), Path: path}
+		summary.Exists = cfg.ListExists(name)
+		if !summary.Exists {
+			summaries = append(summaries, summary)
+			continue
+		}
+
+		st, _, err := cfg.OpenList(name)
+		if err != nil {
+			summary.Error = err.Error()
+			summaries = append(summaries, summary)
+			continue
+		}
+		todos, err := st.List()
+		st.Close()
+		if err != nil {
+			summary.Error = err.Error()
+			summaries = append(summaries, summary)
+			continue
+		}
+
+		summary.Total = len(todos)
+		for _, t := range todos {
+			if !t.Completed {
+				summary.Pending++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// printLists prints every named list with its todo counts
+func printLists(summaries []listSummary) {
+	fmt.Printf("%s%s🗂  Lists%s\n", ColorYellow, ColorBold, ColorReset)
+	fmt.Println()
+	fmt.Printf("   %-20s %-8s %-8s %s\n", "NAME", "TOTAL", "PENDING", "FILE")
+	for _, l := range summaries {
+		marker := " "
+		if l.Current {
+			marker = "*"
+		}
+
+		switch {
+		case l.Error != "":
+			fmt.Printf(" %s %-20s %serror: %s%s\n", marker, l.Name, ColorRed, l.Error, ColorReset)
+		case !l.Exists:
+			fmt.Printf(" %s %-20s %-8d %-8d %s%s (not created yet)%s\n", marker, l.Name, 0, 0, ColorDim, l.Path, ColorReset)
+		default:
+			fmt.Printf(" %s %-20s %-8d %-8d %s%s%s\n", marker, l.Name, l.Total, l.Pending, ColorDim, l.Path, ColorReset)
+		}
+	}
+	fmt.Println()
+}
+
+// moveTodo moves a todo from the current list to the named one and returns
+// it as stored there
+func moveTodo(cfg *config.Config, st store.Store, id int, target string) (todo.Todo, error) {
+	if target == cfg.CurrentList() {
+		return todo.Todo{}, fmt.Errorf("todo #%d is already in list %s", id, target)
+	}
+
+	dst, _, err := cfg.OpenList(target)
+	if err != nil {
+		return todo.Todo{}, fmt.Errorf("opening list %s: %w", target, err)
+	}
+	defer dst.Close()
+
+	moved, err := store.Move(outbox.WithNote(dst, "moved from "+cfg.CurrentList()), outbox.WithNote(st, "moved to "+target), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return todo.Todo{}, fmt.Errorf("todo #%d not found", id)
+	}
+	if err != nil {
+		return todo.Todo{}, fmt.Errorf("moving todo: %w", err)
+	}
+	return moved, nil
+}
+
+// Network functions
+
+// networkResult describes what save, load or sync did
+type networkResult struct {
+	// Action is saved, loaded or synced
+	Action string `json:"action" yaml:"action"`
+	Server string `json:"server" yaml:"server"`
+	// Todos counts the todos both sides now hold, leaving out deleted ones
+	Todos int `json:"todos" yaml:"todos"`
+	// Resolved counts the conflicts sync resolved
+	Resolved int `json:"resolved" yaml:"resolved"`
+	// Renumbered lists the todos from the server whose IDs were taken here
+	Renumbered []renumbered `json:"renumbered,omitempty" yaml:"renumbered,omitempty"`
+}
+
+type renumbered struct {
+	From int `json:"from" yaml:"from"`
+	To   int `json:"to" yaml:"to"`
+}
+
+var networkColumns = []string{"action", "server", "todos", "resolved", "renumbered"}
+
+func networkRow(r networkResult) []string {
+	var moves []string
+	for _, m := range r.Renumbered {
+		moves = append(moves, fmt.Sprintf("%d:%d", m.From, m.To))
+	}
+	return []string{
+		r.Action,
+		r.Server,
+		strconv.Itoa(r.Todos),
+		strconv.Itoa(r.Resolved),
+		strings.Join(moves, " "),
+	}
+}
+
+// print tells people what the network command did
+func (r networkResult) print() {
+	for _, m := range r.Renumbered {
+		printInfo(fmt.Sprintf("Todo #%d from the server is #%d here, as #%d was taken", m.From, m.To, m.From))
+	}
+	if r.Resolved > 0 {
+		printInfo(fmt.Sprintf("Resolved %d conflicts", r.Resolved))
+	}
+	switch r.Action {
+	case "saved":
+		printSuccess(fmt.Sprintf("Successfully saved %d todos to %s", r.Todos, r.Server))
+	case "loaded":
+		printSuccess(fmt.Sprintf("Successfully loaded %d todos from %s", r.Todos, r.Server))
+	default:
+		printSuccess(fmt.Sprintf("Successfully synced %d todos with %s", r.Todos, r.Server))
+	}
+}
+
+// saveToNetwork replaces the server's todos with the local ones. Unless
+// force is set, it only does so if the server's todos are still the ones
+// last loaded or synced here, and returns client.ErrServerChanged otherwise.
+func saveToNetwork(cfg *config.Config, st store.Store, nc client.Config, force bool) (networkResult, error) {
+	basePath, err := cfg.SyncBasePath(nc.ServerURL)
+	if err != nil {
+		return networkResult{}, err
+	}
+	base, err := merge.LoadBase(basePath)
+	if err != nil {
+		return networkResult{}, fmt.Errorf("reading last synced copy: %w", err)
+	}
+	// Read before the todos, so that nothing queued after them is
+	// acknowledged with them
+	box := outbox.Of(st)
+	_, mark, err := box.Pending(basePath)
+	if err != nil {
+		return networkResult{}, fmt.Errorf("reading outbox: %w", err)
+	}
+	// Tombstones go too, so other machines see the deletions
+	todoList, err := store.SnapshotAll(st)
+	if err != nil {
+		return networkResult{}, fmt.Errorf("loading todos: %w", err)
+	}
+
+	var header http.Header
+	switch {
+	case force:
+	case base != nil && base.ETag != "":
+		header = client.IfMatch(base.ETag)
+	default:
+		// Never loaded or synced: only write to a server with no todos
+		header = http.Header{"If-None-Match": {"*"}}
+	}
+	etag, err := client.Replace(nc, todoList, header)
+	if errors.Is(err, client.ErrServerChanged) {
+		return networkResult{}, err
+	}
+	if err != nil {
+		return networkResult{}, fmt.Errorf("saving to network: %w", err)
+	}
+	if err := merge.SaveBase(basePath, &merge.Base{List: todoList, ETag: etag, SyncedAt: time.Now()}); err != nil {
+		return networkResult{}, fmt.Errorf("recording save: %w", err)
+	}
+	if err := box.Ack(basePath, mark); err != nil {
+		return networkResult{}, fmt.Errorf("recording save in outbox: %w", err)
+	}
+	return networkResult{Action: "saved", Server: nc.ServerURL, Todos: len(todo.Live(todoList.Todos))}, nil
+}
+
+// loadFromNetwork replaces the local todos with the server's
+func loadFromNetwork(cfg *config.Config, st store.Store, nc client.Config) (networkResult, error) {
+	basePath, err := cfg.SyncBasePath(nc.ServerURL)
+	if err != nil {
+		return networkResult{}, err
+	}
+	box := outbox.Of(st)
+	_, mark, err := box.Pending(basePath)
+	if err != nil {
+		return networkResult{}, fmt.Errorf("reading outbox: %w", err)
+	}
+	todoList, etag, err := client.Fetch(nc)
+	if err != nil {
+		return networkResult{}, fmt.Errorf("loading from network: %w", err)
+	}
+
+	// The server's todos are recorded as coming from it, so the next sync
+	// does not send them back, while other remotes still get them
+	if err := saveTodos(outbox.WithSource(st, basePath), todoList); err != nil {
+		return networkResult{}, fmt.Errorf("saving to local file: %w", err)
+	}
+	// Both sides now hold the same todos, which later saves and syncs
+	// start from, and the changes queued here were overwritten
+	if err := merge.SaveBase(basePath, &merge.Base{List: todoList, ETag: etag, SyncedAt: time.Now()}); err != nil {
+		return networkResult{}, fmt.Errorf("recording load: %w", err)
+	}
+	if err := box.Ack(basePath, mark); err != nil {
+		return networkResult{}, fmt.Errorf("recording load in outbox: %w", err)
+	}
+	return networkResult{Action: "loaded", Server: nc.ServerURL, Todos: len(todo.Live(todoList.Todos))}, nil
+}
+
+// syncWithNetwork merges the local todos with the server's against the
+// copy saved after the last sync, then writes the result to both sides.
+// Conflicting edits go to resolve; with a nil resolve they are listed and
+// nothing is written.
+func syncWithNetwork(cfg *config.Config, st store.Store, nc client.Config, resolve merge.Resolver) (networkResult, error) {
+	basePath, err := cfg.SyncBasePath(nc.ServerURL)
+	if err != nil {
+		return networkResult{}, err
+	}
+	result, err := client.Sync(nc, st, basePath, resolve)
+	switch {
+	case errors.Is(err, merge.ErrConflict):
+		printConflicts(result.Conflicts)
+		return networkResult{}, fmt.Errorf("%w; nothing was changed. Run again in a terminal to choose, or pass --prefer local or --prefer remote", err)
+	case errors.Is(err, client.ErrUnreachable):
+		if _, pending, _ := client.Status(st, basePath); len(pending) > 0 {
+			return networkResult{}, fmt.Errorf("%w; %d local changes are waiting to be synced, see 'todo sync --status'", err, len(pending))
+		}
+		return networkResult{}, err
+	case errors.Is(err, store.ErrChanged):
+		return networkResult{}, fmt.Errorf("%w; run sync again", err)
+	case err != nil:
+		return networkResult{}, err
+	}
+
+	synced := networkResult{
+		Action:   "synced",
+		Server:   nc.ServerURL,
+		Todos:    len(todo.Live(result.List.Todos)),
+		Resolved: len(result.Conflicts),
+	}
+	for from, to := range result.Renumbered {
+		synced.Renumbered = append(synced.Renumbered, renumbered{From: from, To: to})
+	}
+	slices.SortFunc(synced.Renumbered, func(a, b renumbered) int { return a.From - b.From })
+	return synced, nil
+}
+
+// syncStatus is what 'todo sync --status' reports
+type syncStatus struct {
+	// Remote is the name the server was added as, if any
+	Remote string `json:"remote,omitempty" yaml:"remote,omitempty"`
+	Server string `json:"server" yaml:"server"`
+	// Synced is false if the todos were never loaded or synced here
+	Synced bool `json:"synced" yaml:"synced"`
+	// LastSynced is nil if not synced or if the time was not recorded
+	LastSynced *time.Time      `json:"last_synced" yaml:"last_synced"`
+	Pending    []pendingChange `json:"pending" yaml:"pending"`
+}
+
+// pendingChange is a change made here that the next sync sends
+type pendingChange struct {
+	// Change is added, edited or deleted
+	Change string `json:"change" yaml:"change"`
+	ID     int    `json:"id" yaml:"id"`
+	UUID   string `json:"uuid" yaml:"uuid"`
+	Title  string `json:"title" yaml:"title"`
+	// Fields names the fields an edit changed
+	Fields []string `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Note   string   `json:"note,omitempty" yaml:"note,omitempty"`
+}
+
+// statusColumns has one row per status, so pending is the number of
+// changes; JSON and YAML list them
+var statusColumns = []string{"remote", "server", "synced", "last_synced", "pending"}
+
+func statusRow(s syncStatus) []string {
+	return []string{
+		s.Remote,
+		s.Server,
+		strconv.FormatBool(s.Synced),
+		formatTime(s.LastSynced),
+		strconv.Itoa(len(s.Pending)),
+	}
+}
+
+// readSyncStatus finds when the local todos were last synced with a
+// server and the changes made here since, which the next sync sends. It
+// does not contact the server.
+func readSyncStatus(cfg *config.Config, st store.Store, name, serverURL string) (syncStatus, error) {
+	basePath, err := cfg.SyncBasePath(serverURL)
+	if err != nil {
+		return syncStatus{}, err
+	}
+	base, pending, err := client.Status(st, basePath)
+	if err != nil {
+		return syncStatus{}, err
+	}
+
+	status := syncStatus{Remote: name, Server: serverURL, Synced: base != nil, Pending: pendingChanges(pending)}
+	if base != nil && !base.SyncedAt.IsZero() {
+		status.LastSynced = &base.SyncedAt
+	}
+	return status, nil
+}
+
+func pendingChanges(pending []merge.Pending) []pendingChange {
+	changes := make([]pendingChange, 0, len(pending))
+	for _, p := range pending {
+		changes = append(changes, pendingChange{
+			Change: p.Change,
+			ID:     p.Todo.ID,
+			UUID:   p.Todo.UUID,
+			Title:  p.Todo.Title,
+			Fields: p.Fields,
+			Note:   p.Note,
+		})
+	}
+	return changes
+}
+
+// printSyncStatus shows the status read by readSyncStatus
+func printSyncStatus(status syncStatus) {
+	target := status.Server
+	if status.Remote != "" {
+		target = fmt.Sprintf("%s (%s)", status.Remote, status.Server)
+	}
+	synced := "never"
+	if status.Synced {
+		if status.LastSynced != nil {
+			synced = status.LastSynced.Local().Format("2006-01-02 15:04:05")
+		} else {
+			synced = "unknown"
+		}
+	}
+
+	fmt.Printf("%s%s🔄 Sync status%s\n", ColorYellow, ColorBold, ColorReset)
+	fmt.Println()
+	fmt.Printf("   %-12s %s\n", "Server", target)
+	fmt.Printf("   %-12s %s\n", "Last synced", synced)
+	fmt.Printf("   %-12s %d\n", "Pending", len(status.Pending))
+	if len(status.Pending) > 0 {
+		fmt.Println()
+	}
+	printPending(status.Pending)
+	fmt.Println()
+}
+
+// printPending lists changes one per line, marked + for added, - for
+// deleted and ~ for edited with the fields that changed
+func printPending(pending []pendingChange) {
+	for _, p := range pending {
+		note := ""
+		if p.Note != "" {
+			note = fmt.Sprintf(" %s(%s)%s", ColorDim, p.Note, ColorReset)
+		}
+		switch p.Change {
+		case merge.Added:
+			fmt.Printf("   %s+%s #%-4d %s%s\n", ColorGreen, ColorReset, p.ID, p.Title, note)
+		case merge.Deleted:
+			fmt.Printf("   %s-%s #%-4d %s%s\n", ColorRed, ColorReset, p.ID, p.Title, note)
+		default:
+			fields := ""
+			if len(p.Fields) > 0 {
+				fields = fmt.Sprintf(" %s(%s)%s", ColorDim, strings.Join(p.Fields, ", "), ColorReset)
+			}
+			fmt.Printf("   %s~%s #%-4d %s%s%s\n", ColorYellow, ColorReset, p.ID, p.Title, fields, note)
+		}
+	}
+}
+
+// printConflicts lists conflicts that were left unresolved
+func printConflicts(conflicts []merge.Conflict) {
+	printWarning("Both sides changed:")
+	for _, c := range conflicts {
+		fmt.Fprintf(os.Stderr, "  #%d %s: %s\n", c.ID, c.Title, describeConflict(c))
+	}
+}
+
+func describeConflict(c merge.Conflict) string {
+	if c.Field == merge.FieldDeleted {
+		return fmt.Sprintf("%s locally, %s remotely", c.Local, c.Remote)
+	}
+	return fmt.Sprintf("%s is %s locally, %s remotely (was %s)",
+		c.Field, conflictValue(c.Local), conflictValue(c.Remote), conflictValue(c.Base))
+}
+
+func conflictValue(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+	return strconv.Quote(v)
+}
+
+// promptResolver asks on the terminal which side wins each conflict
+func promptResolver(in io.Reader) merge.Resolver {
+	reader := bufio.NewReader(in)
+	return func(c merge.Conflict) (merge.Side, error) {
+		printWarning(fmt.Sprintf("Conflict in #%d %s: %s", c.ID, c.Title, describeConflict(c)))
+		for {
+			fmt.Fprintf(os.Stderr, "  Keep %s[l]ocal%s or %s[r]emote%s? ", ColorCyan, ColorReset, ColorCyan, ColorReset)
+			answer, err := reader.ReadString('\n')
+			if side, perr := merge.ParseSide(expandSide(strings.TrimSpace(answer))); perr == nil {
+				return side, nil
+			}
+			if err != nil {
+				return merge.Local, fmt.Errorf("no choice made for #%d", c.ID)
+			}
+		}
+	}
+}
+
+// expandSide accepts the one-letter answers to promptResolver
+func expandSide(s string) string {
+	switch strings.ToLower(s) {
+	case "l":
+		return "local"
+	case "r":
+		return "remote"
+	}
+	return s
+}
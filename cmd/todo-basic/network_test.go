@@ -0,0 +1,277 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"todo-bubbletea/client"
+	"todo-bubbletea/config"
+	"todo-bubbletea/server"
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+// machine is one computer syncing with a test server
+type machine struct {
+	cfg *config.Config
+	st  store.Store
+}
+
+func newMachine(t *testing.T, name string) machine {
+	t.Helper()
+	cfg := config.Default()
+	path := filepath.Join(t.TempDir(), name+".json")
+	if err := cfg.SetFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return machine{cfg: cfg, st: store.OpenJSON(path)}
+}
+
+func (m machine) titles(t *testing.T) []string {
+	t.Helper()
+	list, err := loadTodos(m.st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, item := range list.Todos {
+		titles = append(titles, item.Title)
+	}
+	return titles
+}
+
+// newTLSServer starts 'todo serve' over TLS with a fresh store
+func newTLSServer(t *testing.T, start func(*httptest.Server)) *httptest.Server {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	disableColors()
+
+	dir := t.TempDir()
+	open := func(list string) (store.Store, error) {
+		return store.OpenJSON(filepath.Join(dir, list+".json")), nil
+	}
+	ts := httptest.NewUnstartedServer(server.New(open, "todos", nil, 0))
+	start(ts)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// writePEM writes a PEM block to a file in a temporary directory
+func writePEM(t *testing.T, name, kind string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newCert makes a certificate signed by parent, or self-signed if parent
+// is nil, and returns it with its key
+func newCert(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func networkConfigFor(t *testing.T, url string, remote config.Remote) client.Config {
+	t.Helper()
+	remote.URL = url
+	nc, err := client.ForRemote(remote, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nc
+}
+
+func TestNetworkPrivateCA(t *testing.T) {
+	ts := newTLSServer(t, (*httptest.Server).StartTLS)
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", ts.Certificate().Raw)
+
+	alice, bob := newMachine(t, "alice"), newMachine(t, "bob")
+	if _, err := addTodo(alice.st, todo.Todo{Title: "one"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The test server's certificate is not trusted by default
+	_, err := saveToNetwork(alice.cfg, alice.st, networkConfigFor(t, ts.URL, config.Remote{}), false)
+	var certErr *tls.CertificateVerificationError
+	if !errors.As(err, &certErr) {
+		t.Fatalf("save without the CA: got %v, want a certificate error", err)
+	}
+
+	nc := networkConfigFor(t, ts.URL, config.Remote{CACert: caFile})
+	if _, err := saveToNetwork(alice.cfg, alice.st, nc, false); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := loadFromNetwork(bob.cfg, bob.st, nc); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, err := addTodo(bob.st, todo.Todo{Title: "two"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := syncWithNetwork(bob.cfg, bob.st, nc, nil); err != nil {
+		t.Fatalf("sync bob: %v", err)
+	}
+	synced, err := syncWithNetwork(alice.cfg, alice.st, nc, nil)
+	if err != nil {
+		t.Fatalf("sync alice: %v", err)
+	}
+	if synced.Action != "synced" || synced.Todos != 2 || synced.Server != ts.URL {
+		t.Errorf("sync alice reported %+v", synced)
+	}
+	if got := alice.titles(t); len(got) != 2 || got[0] != "one" || got[1] != "two" {
+		t.Errorf("alice has %q after sync, want [one two]", got)
+	}
+
+	if _, err := addTodo(alice.st, todo.Todo{Title: "three"}); err != nil {
+		t.Fatal(err)
+	}
+	status, err := readSyncStatus(alice.cfg, alice.st, "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Synced || status.LastSynced == nil || len(status.Pending) != 1 || status.Pending[0].Title != "three" {
+		t.Errorf("status after adding a todo: %+v", status)
+	}
+}
+
+// TestSaveAndLoadAckOutbox checks that changes a save or load already
+// brought level with the server are not left waiting for the next sync
+func TestSaveAndLoadAckOutbox(t *testing.T) {
+	ts := newTLSServer(t, (*httptest.Server).StartTLS)
+	nc := networkConfigFor(t, ts.URL, config.Remote{Insecure: true})
+
+	// A machine whose changes are queued in an outbox, as the CLI's are
+	c := newMachine(t, "c")
+	path := filepath.Join(t.TempDir(), "c.json")
+	if err := c.cfg.SetFile(path); err != nil {
+		t.Fatal(err)
+	}
+	c.cfg.Remotes = map[string]config.Remote{"test": {URL: ts.URL, Insecure: true}}
+	st, err := c.cfg.OpenRecorded(store.BackendJSON, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.st = st
+	pending := func() int {
+		t.Helper()
+		status, err := readSyncStatus(c.cfg, c.st, "", ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(status.Pending)
+	}
+
+	if _, err := addTodo(c.st, todo.Todo{Title: "one"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := saveToNetwork(c.cfg, c.st, nc, false); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if n := pending(); n != 0 {
+		t.Errorf("%d changes pending after save, want 0", n)
+	}
+
+	if _, err := addTodo(c.st, todo.Todo{Title: "two"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadFromNetwork(c.cfg, c.st, nc); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if n := pending(); n != 0 {
+		t.Errorf("%d changes pending after load, want 0", n)
+	}
+}
+
+func TestNetworkInsecure(t *testing.T) {
+	ts := newTLSServer(t, (*httptest.Server).StartTLS)
+	c := newMachine(t, "c")
+
+	nc := networkConfigFor(t, ts.URL, config.Remote{Insecure: true})
+	if _, err := loadFromNetwork(c.cfg, c.st, nc); err != nil {
+		t.Fatalf("load with --insecure: %v", err)
+	}
+}
+
+func TestNetworkClientCertificate(t *testing.T) {
+	clientCA, clientCAKey := newCert(t, "client CA", nil, nil)
+	cert, key := newCert(t, "alice", clientCA, clientCAKey)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := writePEM(t, "alice.pem", "CERTIFICATE", cert.Raw)
+	keyFile := writePEM(t, "alice.key", "PRIVATE KEY", keyDER)
+
+	ts := newTLSServer(t, func(ts *httptest.Server) {
+		pool := x509.NewCertPool()
+		pool.AddCert(clientCA)
+		ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+		ts.StartTLS()
+	})
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", ts.Certificate().Raw)
+	c := newMachine(t, "c")
+
+	nc := networkConfigFor(t, ts.URL, config.Remote{CACert: caFile})
+	if _, err := loadFromNetwork(c.cfg, c.st, nc); err == nil {
+		t.Fatal("load without a client certificate succeeded")
+	}
+
+	nc = networkConfigFor(t, ts.URL, config.Remote{CACert: caFile, ClientCert: certFile, ClientKey: keyFile})
+	if _, err := loadFromNetwork(c.cfg, c.st, nc); err != nil {
+		t.Fatalf("load with a client certificate: %v", err)
+	}
+}
+
+func TestReadSecretKeepsGlobalsOffURLs(t *testing.T) {
+	t.Setenv(config.PasswordEnv, "global")
+	remote := config.Remote{URL: "https://todos.example.com", User: "alice"}
+
+	if secret, err := readSecret("team", remote); err != nil || secret != "global" {
+		t.Errorf("named remote: readSecret = %q, %v, want the global password", secret, err)
+	}
+	if secret, err := readSecret("", remote); err == nil {
+		t.Errorf("a URL given on the command line was sent %s: %q", config.PasswordEnv, secret)
+	}
+	remote.URL = defaultServerURL
+	if secret, err := readSecret("", remote); err != nil || secret != "global" {
+		t.Errorf("default server: readSecret = %q, %v, want the global password", secret, err)
+	}
+}
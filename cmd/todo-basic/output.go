@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+
+	"todo-bubbletea/todo"
+)
+
+// Formats accepted by --output
+const (
+	outputTable  = "table"
+	outputJSON   = "json"
+	outputYAML   = "yaml"
+	outputCSV    = "csv"
+	outputTSV    = "tsv"
+	outputNDJSON = "ndjson"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML, outputCSV, outputTSV, outputNDJSON}
+
+// useColor reports whether to color output: stdout must be a terminal and
+// NO_COLOR (https://no-color.org) must be unset
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// output renders a command's results either as the table and messages
+// meant for people or in the machine-readable form picked with --output or
+// --format
+type output struct {
+	format   string
+	template string
+
+	tmpl *template.Template
+	w    io.Writer
+}
+
+// register adds --output and --format to cmd and checks them before it runs
+func (o *output) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.format, "output", "o", outputTable, "output format: "+strings.Join(outputFormats, ", "))
+	cmd.Flags().StringVar(&o.template, "format", "", "Go template applied to each result, e.g. '{{.ID}} {{.Title}}'")
+	cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("format", cobra.NoFileCompletions)
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		return o.check(cmd)
+	}
+}
+
+func (o *output) check(cmd *cobra.Command) error {
+	o.w = cmd.OutOrStdout()
+	if o.template != "" {
+		if cmd.Flags().Changed("output") {
+			return fmt.Errorf("--output and --format cannot be used together")
+		}
+		tmpl, err := template.New("format").Funcs(templateFuncs).Parse(o.template)
+		if err != nil {
+			return fmt.Errorf("invalid --format: %w", err)
+		}
+		o.tmpl = tmpl
+		return nil
+	}
+	for _, f := range outputFormats {
+		if o.format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --output %q (want one of %s)", o.format, strings.Join(outputFormats, ", "))
+}
+
+// templateFuncs are available to --format templates
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"date": func(layout string, t any) string {
+		switch t := t.(type) {
+		case time.Time:
+			return t.Format(layout)
+		case *time.Time:
+			if t != nil {
+				return t.Format(layout)
+			}
+		}
+		return ""
+	},
+}
+
+// machine reports whether results are written for programs rather than people
+func (o *output) machine() bool {
+	return o.tmpl != nil || o.format != outputTable
+}
+
+// progress tells people what a command is doing, keeping quiet when stdout
+// is for the result
+func (o *output) progress(message string) {
+	if !o.machine() {
+		printProgress(message)
+	}
+}
+
+// todos writes a list of todos, calling table for the human-readable form
+func (o *output) todos(todos []todo.Todo, table func()) error {
+	return writeRecords(o, todos, false, table, todoColumns, todoRow)
+}
+
+// todo writes the single todo a command acted on, calling text for the
+// human-readable form
+func (o *output) todo(t todo.Todo, text func()) error {
+	return writeRecords(o, []todo.Todo{t}, true, text, todoColumns, todoRow)
+}
+
+// lists writes the summaries for 'todo lists'
+func (o *output) lists(summaries []listSummary, table func()) error {
+	return writeRecords(o, summaries, false, table, listColumns, listRow)
+}
+
+// network writes what save, load or sync did
+func (o *output) network(r networkResult, text func()) error {
+	return writeRecords(o, []networkResult{r}, true, text, networkColumns, networkRow)
+}
+
+// status writes the report of 'todo sync --status'
+func (o *output) status(s syncStatus, text func()) error {
+	return writeRecords(o, []syncStatus{s}, true, text, statusColumns, statusRow)
+}
+
+// gc writes what 'todo gc' purged
+func (o *output) gc(r gcResult, text func()) error {
+	return writeRecords(o, []gcResult{r}, true, text, gcColumns, gcRow)
+}
+
+// backups writes the snapshots for 'todo backup list'
+func (o *output) backups(versions []backupVersion, table func()) error {
+	return writeRecords(o, versions, false, table, backupColumns, backupRow)
+}
+
+// migrate writes what 'todo migrate-store' copied
+func (o *output) migrate(r migrateResult, text func()) error {
+	return writeRecords(o, []migrateResult{r}, true, text, migrateColumns, migrateRow)
+}
+
+// upload writes the backup 'todo upload' made
+func (o *output) upload(r uploadResult, text func()) error {
+	return writeRecords(o, []uploadResult{r}, true, text, uploadColumns, uploadRow)
+}
+
+// download writes what 'todo download' merged or would merge
+func (o *output) download(r downloadResult, text func()) error {
+	return writeRecords(o, []downloadResult{r}, true, text, downloadColumns, downloadRow)
+}
+
+// restore writes the backup 'todo restore' brought back
+func (o *output) restore(r restoreResult, text func()) error {
+	return writeRecords(o, []restoreResult{r}, true, text, restoreColumns, restoreRow)
+}
+
+// remotes writes the named servers for 'todo remote list'
+func (o *output) remotes(summaries []remoteSummary, table func()) error {
+	return writeRecords(o, summaries, false, table, remoteColumns, remoteRow)
+}
+
+var todoColumns = []string{"id", "uuid", "title", "description", "completed", "priority", "category", "due_date", "created_at", "completed_at", "updated_at"}
+
+func todoRow(t todo.Todo) []string {
+	return []string{
+		strconv.Itoa(t.ID),
+		t.UUID,
+		t.Title,
+		t.Description,
+		strconv.FormatBool(t.Completed),
+		t.Priority,
+		t.Category,
+		formatTime(t.DueDate),
+		t.CreatedAt.Format(time.RFC3339),
+		formatTime(t.CompletedAt),
+		formatTime(t.UpdatedAt),
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// writeRecords writes items in the chosen format. JSON and YAML hold an
+// array, or a single object when single is set; NDJSON, CSV, TSV and
+// templates write one line per item.
+func writeRecords[T any](o *output, items []T, single bool, human func(), columns []string, row func(T) []string) error {
+	if !o.machine() {
+		human()
+		return nil
+	}
+
+	if o.tmpl != nil {
+		for _, item := range items {
+			if err := o.tmpl.Execute(o.w, item); err != nil {
+				return err
+			}
+			fmt.Fprintln(o.w)
+		}
+		return nil
+	}
+
+	var value any = items
+	if single && len(items) == 1 {
+		value = items[0]
+	}
+
+	switch o.format {
+	case outputJSON:
+		enc := json.NewEncoder(o.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+
+	case outputYAML:
+		enc := yaml.NewEncoder(o.w)
+		enc.SetIndent(2)
+		if err := enc.Encode(value); err != nil {
+			return err
+		}
+		return enc.Close()
+
+	case outputNDJSON:
+		enc := json.NewEncoder(o.w)
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case outputCSV, outputTSV:
+		w := csv.NewWriter(o.w)
+		if o.format == outputTSV {
+			w.Comma = '\t'
+		}
+		w.Write(columns)
+		for _, item := range items {
+			w.Write(row(item))
+		}
+		w.Flush()
+		return w.Error()
+	}
+	return nil
+}
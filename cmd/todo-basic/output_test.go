@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+// newCLI sets up a config that backs up to a directory and names one
+// remote, and returns the todo file holding two todos
+func newCLI(t *testing.T) string {
+	t.Helper()
+	for _, env := range []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME"} {
+		t.Setenv(env, t.TempDir())
+	}
+	disableColors()
+
+	dir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "todo")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := json.Marshal(map[string]any{
+		"backup":  map[string]any{"type": "dir", "path": t.TempDir()},
+		"remotes": map[string]any{"team": map[string]any{"url": "https://todos.example.com", "user": "alice"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), cfg, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "todos.json")
+	st := store.OpenJSON(path)
+	for _, title := range []string{"Milk", "Bread"} {
+		if _, err := addTodo(st, todo.Todo{Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// runJSON runs todo with args and -o json, and decodes what it printed
+// into v
+func runJSON(t *testing.T, v any, args ...string) {
+	t.Helper()
+	var buf bytes.Buffer
+	a := &app{}
+	defer a.close()
+	root := newRootCmd(a)
+	root.SetArgs(append(args, "-o", "json"))
+	root.SetOut(&buf)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("todo %v: %v", args, err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), v); err != nil {
+		t.Fatalf("todo %v printed %q: %v", args, buf.String(), err)
+	}
+}
+
+func TestMigrateStoreOutput(t *testing.T) {
+	file := newCLI(t)
+	dst := filepath.Join(t.TempDir(), "todos.db")
+
+	var migrated migrateResult
+	runJSON(t, &migrated, "--file", file, "migrate-store", "sqlite", dst)
+	if migrated.Backend != store.BackendSQLite || migrated.Path != dst || migrated.Copied != 2 {
+		t.Errorf("migrate-store printed %+v", migrated)
+	}
+}
+
+func TestBackupOutput(t *testing.T) {
+	file := newCLI(t)
+
+	var uploaded uploadResult
+	runJSON(t, &uploaded, "--file", file, "upload")
+	if uploaded.ID == "" || uploaded.Name == "" || uploaded.Todos != 2 || uploaded.Pruned != 0 {
+		t.Errorf("upload printed %+v", uploaded)
+	}
+
+	var downloaded downloadResult
+	runJSON(t, &downloaded, "--file", file, "download", "--dry-run")
+	if downloaded.From == "" || !downloaded.DryRun || downloaded.Changes == nil || len(downloaded.Changes) != 0 {
+		t.Errorf("download --dry-run printed %+v", downloaded)
+	}
+
+	st := store.OpenJSON(file)
+	if err := st.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	var restored restoreResult
+	runJSON(t, &restored, "--file", file, "restore", "--force")
+	if restored.From == "" || restored.Todos != 2 {
+		t.Errorf("restore printed %+v", restored)
+	}
+}
+
+func TestRemoteListOutput(t *testing.T) {
+	newCLI(t)
+
+	var remotes []remoteSummary
+	runJSON(t, &remotes, "remote", "list")
+	if len(remotes) != 1 {
+		t.Fatalf("remote list printed %+v, want one remote", remotes)
+	}
+	if r := remotes[0]; r.Name != "team" || r.URL != "https://todos.example.com" || !r.Default || r.Auth == "" {
+		t.Errorf("remote list printed %+v", r)
+	}
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"todo-bubbletea/client"
+	"todo-bubbletea/config"
+)
+
+func newRemoteCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remote",
+		Short: "Manage the todo servers known by name",
+		Long: `Remote manages named todo servers for save, load and sync, which then
+take the remote's name instead of a URL.
+
+Passwords and tokens are never stored. They are read from the environment
+variable named by --password-env, or else from the output of
+--password-command. Remotes with neither read ` + config.PasswordEnv + ` (or
+` + config.TokenEnv + ` for bearer tokens), as does the default server. Failing
+that, and always for a URL given in place of a remote, they are asked for
+on the terminal.
+
+Servers with a private certificate authority need --ca-cert, and ones
+requiring mutual TLS --client-cert and --client-key.`,
+		Example: `  todo remote add team https://todos.example.com --user alice --default
+  todo remote add ci https://todos.example.com --token --password-env CI_TODO_TOKEN
+  todo remote add home http://nas:8080 --user me --password-command "pass show todo/home"
+  todo remote add office https://todos.internal --ca-cert office-ca.pem
+  todo sync team`,
+	}
+	cmd.AddCommand(newRemoteAddCmd(a), newRemoteListCmd(a), newRemoteRemoveCmd(a))
+	return cmd
+}
+
+func newRemoteAddCmd(a *app) *cobra.Command {
+	var remote config.Remote
+	var bearer, makeDefault bool
+
+	cmd := &cobra.Command{
+		Use:   "add <name> <url>",
+		Short: "Add a named todo server",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := config.ValidateRemoteName(name); err != nil {
+				return err
+			}
+			if err := validateServerURL(args[1]); err != nil {
+				return err
+			}
+			cfg, err := a.config()
+			if err != nil {
+				return err
+			}
+			if _, ok := cfg.Remotes[name]; ok {
+				return fmt.Errorf("remote %s already exists; remove it first to change it", name)
+			}
+
+			remote.URL = args[1]
+			if err := absTLSPaths(&remote); err != nil {
+				return err
+			}
+			if bearer {
+				remote.Auth = config.AuthBearer
+			}
+			remotes := make(map[string]config.Remote, len(cfg.Remotes)+1)
+			for n, r := range cfg.Remotes {
+				remotes[n] = r
+			}
+			remotes[name] = remote
+			if err := config.Set("remotes", remotes); err != nil {
+				return err
+			}
+			if makeDefault {
+				if err := config.Set("remote", name); err != nil {
+					return err
+				}
+			}
+			printSuccess(fmt.Sprintf("Added remote %s: %s", name, remote.URL))
+			return nil
+		},
+		ValidArgsFunction: cobra.NoFileCompletions,
+	}
+	cmd.Flags().StringVarP(&remote.User, "user", "u", "", "username for basic auth")
+	cmd.Flags().BoolVar(&bearer, "token", false, "authenticate with a bearer token instead of a username and password")
+	cmd.Flags().StringVar(&remote.PasswordEnv, "password-env", "", "environment variable holding the password or token")
+	cmd.Flags().StringVar(&remote.PasswordCommand, "password-command", "", "shell command that prints the password or token")
+	cmd.Flags().BoolVar(&makeDefault, "default", false, "use this remote when none is given")
+	registerTLSFlags(cmd, &remote)
+	cmd.MarkFlagsMutuallyExclusive("user", "token")
+	return cmd
+}
+
+// remoteSummary is one remote as 'todo remote list' shows it
+type remoteSummary struct {
+	Name    string `json:"name" yaml:"name"`
+	URL     string `json:"url" yaml:"url"`
+	Default bool   `json:"default" yaml:"default"`
+	Auth    string `json:"auth" yaml:"auth"`
+	TLS     string `json:"tls,omitempty" yaml:"tls,omitempty"`
+}
+
+var remoteColumns = []string{"name", "url", "default", "auth", "tls"}
+
+func remoteRow(r remoteSummary) []string {
+	return []string{r.Name, r.URL, strconv.FormatBool(r.Default), r.Auth, r.TLS}
+}
+
+// printRemotes shows remotes as a table, marking the default
+func printRemotes(remotes []remoteSummary) {
+	if len(remotes) == 0 {
+		printInfo("No remotes yet; add one with 'todo remote add <name> <url>'")
+		return
+	}
+	fmt.Printf("%s%s🌐 Remotes%s\n", ColorYellow, ColorBold, ColorReset)
+	fmt.Println()
+	fmt.Printf("   %-12s %-40s %s\n", "NAME", "URL", "AUTH")
+	for _, r := range remotes {
+		marker := " "
+		if r.Default {
+			marker = "*"
+		}
+		auth := r.Auth
+		if r.TLS != "" {
+			auth += "; " + r.TLS
+		}
+		fmt.Printf(" %s %-12s %-40s %s%s%s\n", marker, r.Name, r.URL, ColorDim, auth, ColorReset)
+	}
+	fmt.Println()
+}
+
+func newRemoteListCmd(a *app) *cobra.Command {
+	var out output
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "Show the named todo servers",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := a.config()
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(cfg.Remotes))
+			for name := range cfg.Remotes {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			remotes := make([]remoteSummary, 0, len(names))
+			for _, name := range names {
+				r := cfg.Remotes[name]
+				remotes = append(remotes, remoteSummary{
+					Name:    name,
+					URL:     r.URL,
+					Default: name == cfg.DefaultRemoteName(),
+					Auth:    describeAuth(r),
+					TLS:     describeTLS(r),
+				})
+			}
+			return out.remotes(remotes, func() { printRemotes(remotes) })
+		},
+	}
+	out.register(cmd)
+	return cmd
+}
+
+func newRemoteRemoveCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <name>",
+		Aliases: []string{"rm"},
+		Short:   "Forget a named todo server",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			cfg, err := a.config()
+			if err != nil {
+				return err
+			}
+			if _, ok := cfg.Remotes[name]; !ok {
+				return fmt.Errorf("no remote named %s", name)
+			}
+
+			remotes := make(map[string]config.Remote, len(cfg.Remotes))
+			for n, r := range cfg.Remotes {
+				if n != name {
+					remotes[n] = r
+				}
+			}
+			var value any
+			if len(remotes) > 0 {
+				value = remotes
+			}
+			if err := config.Set("remotes", value); err != nil {
+				return err
+			}
+			if cfg.Remote == name {
+				if err := config.Set("remote", nil); err != nil {
+					return err
+				}
+			}
+			printSuccess(fmt.Sprintf("Removed remote %s", name))
+			return nil
+		},
+		ValidArgsFunction: a.completeRemotes,
+	}
+}
+
+// describeAuth summarises how a remote authenticates, for 'todo remote list'
+func describeAuth(r config.Remote) string {
+	var auth string
+	switch {
+	case r.Bearer():
+		auth = "bearer token"
+	case r.User != "":
+		auth = "user " + r.User
+	case r.ClientCert != "":
+		return "client certificate"
+	default:
+		return "none"
+	}
+	switch {
+	case r.PasswordEnv != "":
+		auth += " from $" + r.PasswordEnv
+	case r.PasswordCommand != "":
+		auth += " from command"
+	}
+	if r.ClientCert != "" {
+		auth += ", client certificate"
+	}
+	return auth
+}
+
+// describeTLS summarises how a remote's server certificate is checked, for
+// 'todo remote list'
+func describeTLS(r config.Remote) string {
+	var parts []string
+	if r.CACert != "" {
+		parts = append(parts, "CA "+r.CACert)
+	}
+	if r.Insecure {
+		parts = append(parts, "certificate not checked")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func validateServerURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid server URL %q: want http:// or https://", raw)
+	}
+	return nil
+}
+
+// networkFlags are the flags shared by the network commands
+type networkFlags struct {
+	user string
+	// password is the deprecated --password
+	password string
+	// tls holds --ca-cert, --client-cert, --client-key and --insecure
+	tls config.Remote
+}
+
+// registerTLSFlags adds the flags for a server's TLS settings, stored in
+// remote
+func registerTLSFlags(cmd *cobra.Command, remote *config.Remote) {
+	cmd.Flags().StringVar(&remote.CACert, "ca-cert", "", "PEM file of extra certificate authorities to trust for the server")
+	cmd.Flags().StringVar(&remote.ClientCert, "client-cert", "", "PEM client certificate for servers requiring mutual TLS")
+	cmd.Flags().StringVar(&remote.ClientKey, "client-key", "", "PEM key for --client-cert")
+	cmd.Flags().BoolVar(&remote.Insecure, "insecure", false, "do not check the server's certificate")
+	cmd.MarkFlagsRequiredTogether("client-cert", "client-key")
+}
+
+// absTLSPaths makes the certificate paths given on the command line
+// absolute, as relative ones in the config file are taken from the config
+// directory
+func absTLSPaths(remote *config.Remote) error {
+	for _, path := range []*string{&remote.CACert, &remote.ClientCert, &remote.ClientKey} {
+		if *path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*path)
+		if err != nil {
+			return err
+		}
+		*path = abs
+	}
+	return nil
+}
+
+// networkConfig works out the server, credentials and TLS settings for a
+// network command from its optional remote-or-URL argument and its flags
+func (a *app) networkConfig(args []string, flags networkFlags) (client.Config, error) {
+	name, remote, err := a.resolveRemote(args, flags)
+	if err != nil {
+		return client.Config{}, err
+	}
+
+	secret := flags.password
+	if secret == "" && (remote.Bearer() || remote.User != "") {
+		if secret, err = readSecret(name, remote); err != nil {
+			return client.Config{}, err
+		}
+	}
+	nc, err := client.ForRemote(remote, secret)
+	if err != nil {
+		return client.Config{}, fmt.Errorf("remote %s: %w", cmp.Or(name, remote.URL), err)
+	}
+	if remote.Insecure {
+		printWarning(fmt.Sprintf("Not checking the certificate of %s", remote.URL))
+	}
+	nc.Notify = printInfo
+	return nc, nil
+}
+
+// resolveRemote finds the server a network command talks to from its
+// optional remote-or-URL argument and its flags, without reading any
+// secret. name is "" for a URL.
+func (a *app) resolveRemote(args []string, flags networkFlags) (name string, remote config.Remote, err error) {
+	cfg, err := a.config()
+	if err != nil {
+		return "", config.Remote{}, err
+	}
+
+	target := ""
+	if len(args) > 0 {
+		target = args[0]
+	} else {
+		target = cfg.DefaultRemoteName()
+	}
+	switch r, ok := cfg.Remotes[target]; {
+	case target == "":
+		remote = config.Remote{URL: defaultServerURL}
+	case ok:
+		name, remote = target, r
+	case strings.Contains(target, "://"):
+		if err := validateServerURL(target); err != nil {
+			return "", config.Remote{}, err
+		}
+		remote = config.Remote{URL: target}
+	default:
+		return "", config.Remote{}, fmt.Errorf("no remote named %s; add it with 'todo remote add %s <url>'", target, target)
+	}
+	if flags.user != "" {
+		remote.User, remote.Auth = flags.user, config.AuthBasic
+	}
+
+	// Certificates given on the command line replace the remote's
+	certs := flags.tls
+	if err := absTLSPaths(&certs); err != nil {
+		return "", config.Remote{}, err
+	}
+	if certs.CACert != "" {
+		remote.CACert = certs.CACert
+	}
+	if certs.ClientCert != "" {
+		remote.ClientCert, remote.ClientKey = certs.ClientCert, certs.ClientKey
+	}
+	remote.Insecure = remote.Insecure || certs.Insecure
+	return name, remote, nil
+}
+
+// readSecret finds the password or token for remote, which is called name
+// or is unnamed for a URL given on the command line, asking for it on a
+// terminal when the remote has no other source. The secret for a URL other
+// than the default server is always asked for, as one in the environment
+// may be meant for another server.
+func readSecret(name string, remote config.Remote) (string, error) {
+	if name != "" || remote.URL == defaultServerURL {
+		if secret, err := remote.Secret(); secret != "" || err != nil {
+			return secret, err
+		}
+	}
+
+	what := "password for " + remote.User
+	if remote.Bearer() {
+		what = "token"
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return readPassword(fmt.Sprintf("%s%s at %s: ", strings.ToUpper(what[:1]), what[1:], remote.URL))
+	}
+
+	hint := "run in a terminal, or add the server with 'todo remote add' to read it from the environment or a command"
+	switch {
+	case name != "" && (remote.PasswordEnv != "" || remote.PasswordCommand != ""):
+		hint = fmt.Sprintf("set %s, or run in a terminal", remote.PasswordEnv)
+	case name != "":
+		hint = fmt.Sprintf("set %s, or give remote %s a password_env or password_command", remote.SecretEnv(), name)
+	case remote.URL == defaultServerURL:
+		hint = "set " + remote.SecretEnv() + " or run in a terminal"
+	}
+	return "", fmt.Errorf("no %s at %s: %s", what, remote.URL, hint)
+}
+
+// readPassword prompts on the terminal and reads a line without echoing
+// it. The prompt goes to stderr, so it stays out of results piped to
+// another program.
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+func (a *app) completeRemotes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, err := a.config()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	names := make([]string, 0, len(cfg.Remotes))
+	for name := range cfg.Remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
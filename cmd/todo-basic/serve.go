@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"todo-bubbletea/config"
+	"todo-bubbletea/server"
+	"todo-bubbletea/store"
+)
+
+func newServeCmd(a *app) *cobra.Command {
+	var addr, usersFile string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve todos over HTTP for save, load and sync",
+		Long: `Serve runs the ` + apiEndpoint + ` endpoint that save, load and sync talk to,
+on top of the local store, so a team can share a list with this binary.
+
+Accounts come from the users file, one "name:bcrypt-hash" line each as
+written by 'htpasswd -B' or 'todo serve passwd'. A third field names a
+list of the user's own; everyone else shares the list given with --list.
+With no users file the server asks for no password at all. Clients sign
+in with basic auth; bearer tokens are refused.
+
+Besides GET and POST on ` + apiEndpoint + `, single todos can be read and
+changed with GET, PUT, PATCH and DELETE on ` + apiEndpoint + `/{id}, where {id}
+is the todo's ID or UUID.`,
+		Example: `  todo serve passwd alice
+  todo --list team serve --addr :9000`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := a.config()
+			if err != nil {
+				return err
+			}
+			if usersFile == "" {
+				if usersFile, err = cfg.UsersFile(); err != nil {
+					return err
+				}
+			}
+			users, err := server.LoadUsers(usersFile)
+			if err != nil {
+				return err
+			}
+			for _, u := range users {
+				if u.List != "" && u.List != config.DefaultList {
+					if err := config.ValidateListName(u.List); err != nil {
+						return fmt.Errorf("list for user %s: %w", u.Name, err)
+					}
+				}
+			}
+			if addr == "" {
+				addr = cfg.Server.Addr
+			}
+			cursorAge, err := cfg.TombstoneAge()
+			if err != nil {
+				return err
+			}
+
+			// The server keeps its own change feed, so its stores need no
+			// outbox
+			srv := server.New(func(list string) (store.Store, error) {
+				path, err := cfg.ListPath(list)
+				if err != nil {
+					return nil, err
+				}
+				return store.Open(cfg.Store.Backend, path)
+			}, cfg.CurrentList(), users, cursorAge)
+			defer srv.Close()
+
+			if len(users) == 0 {
+				printWarning(fmt.Sprintf("No users in %s: anyone who can reach %s can read and change your todos. Add one with 'todo serve passwd <name>'.", usersFile, addr))
+			}
+			printInfo(fmt.Sprintf("Serving list %s on %s%s (Ctrl+C to stop)", cfg.CurrentList(), addr, apiEndpoint))
+			return listenAndServe(cmd.Context(), &http.Server{
+				Addr:              addr,
+				Handler:           srv,
+				ReadHeaderTimeout: 10 * time.Second,
+			})
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "", "address to listen on (default from the config file, or :8080)")
+	cmd.Flags().StringVar(&usersFile, "users", "", "users file (default users in the config directory)")
+	cmd.AddCommand(newServePasswdCmd(a, &usersFile))
+	return cmd
+}
+
+// listenAndServe runs srv until it fails or the process is interrupted,
+// then lets requests in flight finish
+func listenAndServe(ctx context.Context, srv *http.Server) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	printInfo("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func newServePasswdCmd(a *app, usersFile *string) *cobra.Command {
+	var userList string
+	var remove bool
+
+	cmd := &cobra.Command{
+		Use:   "passwd <user>",
+		Short: "Add a server user or change their password",
+		Long: "Passwd adds a user to the users file, or changes their password. The\n" +
+			"password is read from the terminal, or from the first line of stdin.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if strings.ContainsAny(name, ": \t") {
+				return fmt.Errorf("invalid user name %q", name)
+			}
+			if userList != "" && userList != config.DefaultList {
+				if err := config.ValidateListName(userList); err != nil {
+					return fmt.Errorf("invalid --user-list: %w", err)
+				}
+			}
+
+			cfg, err := a.config()
+			if err != nil {
+				return err
+			}
+			path := *usersFile
+			if path == "" {
+				if path, err = cfg.UsersFile(); err != nil {
+					return err
+				}
+			}
+			users, err := server.LoadUsers(path)
+			if err != nil {
+				return err
+			}
+			i := slices.IndexFunc(users, func(u server.User) bool { return u.Name == name })
+
+			if remove {
+				if i < 0 {
+					return fmt.Errorf("no user %s in %s", name, path)
+				}
+				users = slices.Delete(users, i, i+1)
+				if err := server.SaveUsers(path, users); err != nil {
+					return err
+				}
+				printSuccess(fmt.Sprintf("Removed user %s", name))
+				return nil
+			}
+
+			password, err := readNewPassword()
+			if err != nil {
+				return err
+			}
+			hash, err := server.HashPassword(password)
+			if err != nil {
+				return err
+			}
+			if i < 0 {
+				users = append(users, server.User{Name: name})
+				i = len(users) - 1
+			}
+			users[i].PasswordHash = hash
+			if cmd.Flags().Changed("user-list") {
+				users[i].List = userList
+			}
+
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			if err := server.SaveUsers(path, users); err != nil {
+				return err
+			}
+			printSuccess(fmt.Sprintf("Saved user %s in %s", name, path))
+			return nil
+		},
+		ValidArgsFunction: cobra.NoFileCompletions,
+	}
+	cmd.Flags().StringVar(&userList, "user-list", "", "give the user a list of their own instead of the shared one")
+	cmd.Flags().BoolVar(&remove, "remove", false, "remove the user instead")
+	cmd.RegisterFlagCompletionFunc("user-list", a.completeLists)
+	return cmd
+}
+
+// readNewPassword asks for a password twice on a terminal, or reads one
+// line from stdin otherwise
+func readNewPassword() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		password := strings.TrimRight(line, "\r\n")
+		if password == "" {
+			if err != nil {
+				return "", fmt.Errorf("reading password: %w", err)
+			}
+			return "", fmt.Errorf("password cannot be empty")
+		}
+		return password, nil
+	}
+
+	first, err := readPassword("Password: ")
+	if err != nil {
+		return "", err
+	}
+	if first == "" {
+		return "", fmt.Errorf("password cannot be empty")
+	}
+	second, err := readPassword("Repeat password: ")
+	if err != nil {
+		return "", err
+	}
+	if first != second {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return first, nil
+}
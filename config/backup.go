@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// BackupSecretEnv holds the WebDAV password or S3 secret key for backups
+const BackupSecretEnv = "TODO_BACKUP_PASSWORD"
+
+// Where backups can be kept, for BackupConfig.Type
+const (
+	BackupDrive  = "drive"
+	BackupWebDAV = "webdav"
+	BackupS3     = "s3"
+	BackupDir    = "dir"
+	BackupGit    = "git"
+)
+
+// BackupTypes lists the places backups can be kept
+var BackupTypes = []string{BackupDrive, BackupWebDAV, BackupS3, BackupDir, BackupGit}
+
+// BackupConfig says where upload, download and restore keep backups and
+// how many they keep
+type BackupConfig struct {
+	// Type is one of BackupTypes; empty means Google Drive, set up under
+	// "drive"
+	Type string `json:"type,omitempty"`
+	// URL is the WebDAV collection backups go in, or the S3 endpoint such
+	// as https://s3.eu-west-1.amazonaws.com
+	URL string `json:"url,omitempty"`
+	// Bucket and Region locate S3 storage; Region defaults to us-east-1
+	Bucket string `json:"bucket,omitempty"`
+	Region string `json:"region,omitempty"`
+	// Path is the directory or git repository backups go in, relative to
+	// the data directory and "backups" by default. For S3 it is the
+	// prefix of the backups' keys.
+	Path string `json:"path,omitempty"`
+	// Push makes a git repository push each commit to its upstream
+	Push bool `json:"push,omitempty"`
+	// User is the WebDAV user or the S3 access key ID
+	User string `json:"user,omitempty"`
+	// PasswordEnv and PasswordCommand give the WebDAV password or S3
+	// secret key, as for remotes, when BackupSecretEnv is not set
+	PasswordEnv     string `json:"password_env,omitempty"`
+	PasswordCommand string `json:"password_command,omitempty"`
+	// Keep says which backups are kept when a new one is uploaded
+	Keep BackupRetention `json:"keep"`
+}
+
+// BackupRetention says which backups of a list to keep: the newest Last
+// ones, the newest of each of the past Daily days and the newest of each
+// of the past Monthly months, counting the current day and month. Anything
+// else is deleted.
+type BackupRetention struct {
+	Last    int `json:"last"`
+	Daily   int `json:"daily"`
+	Monthly int `json:"monthly"`
+}
+
+// BackupType returns where backups are kept, checking the setting
+func (c *Config) BackupType() (string, error) {
+	switch t := c.Backup.Type; {
+	case t == "":
+		return BackupDrive, nil
+	case slices.Contains(BackupTypes, t):
+		return t, nil
+	default:
+		return "", fmt.Errorf("backup.type: unknown type %q (want one of %v)", t, BackupTypes)
+	}
+}
+
+// BackupDir returns the absolute path of the directory or git repository
+// backups are kept in
+func (c *Config) BackupDir() (string, error) {
+	path := c.Backup.Path
+	if path == "" {
+		path = "backups"
+	}
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, path), nil
+}
+
+// Secret returns the WebDAV password or S3 secret key from
+// BackupSecretEnv, from PasswordEnv or from PasswordCommand, in that
+// order; for S3, AWS_SECRET_ACCESS_KEY is tried before the command. It is
+// "" if none of them has it.
+func (b BackupConfig) Secret() (string, error) {
+	envs := []string{BackupSecretEnv, b.PasswordEnv}
+	if b.Type == BackupS3 {
+		envs = append(envs, "AWS_SECRET_ACCESS_KEY")
+	}
+	return lookupSecret(envs, b.PasswordCommand)
+}
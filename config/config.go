@@ -0,0 +1,219 @@
+// Package config loads the settings shared by the todo CLI and TUI and
+// resolves where data, configuration and credentials live. Locations follow
+// the XDG base directory spec: data under $XDG_DATA_HOME/todo and settings
+// and credentials under $XDG_CONFIG_HOME/todo.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"todo-bubbletea/store"
+)
+
+// appName is the directory created under the XDG base directories
+const appName = "todo"
+
+// FileEnv names the environment variable that overrides the data file
+const FileEnv = "TODO_FILE"
+
+// Config holds the user's settings
+type Config struct {
+	Store  StoreConfig       `json:"store"`
+	Drive  GoogleDriveConfig `json:"drive"`
+	Backup BackupConfig      `json:"backup"`
+	Sync   SyncConfig        `json:"sync"`
+	Server ServerConfig      `json:"server"`
+	// List is the named list opened when none is given with --list
+	List string `json:"list,omitempty"`
+	// Remotes are the todo servers known by name
+	Remotes map[string]Remote `json:"remotes,omitempty"`
+	// Remote names the remote used when a network command is given none
+	Remote string `json:"remote,omitempty"`
+}
+
+// StoreConfig selects where todos are kept
+type StoreConfig struct {
+	// Backend is "json" (the default), "sqlite" or "bolt"
+	Backend string `json:"backend,omitempty"`
+	// Path is the data file. Relative paths are taken from the data
+	// directory; empty means the backend's default file there.
+	Path string `json:"path,omitempty"`
+}
+
+// GoogleDriveConfig holds Google Drive configuration. Relative paths are
+// taken from the config directory.
+type GoogleDriveConfig struct {
+	CredentialsFile string `json:"credentials_file,omitempty"`
+	TokenFile       string `json:"token_file,omitempty"`
+	// Folder is the Drive folder backups are uploaded to
+	Folder string `json:"folder,omitempty"`
+}
+
+// ServerConfig holds the settings for 'todo serve'
+type ServerConfig struct {
+	// Addr is the address to listen on, such as ":8080"
+	Addr string `json:"addr,omitempty"`
+	// UsersFile lists the accounts allowed to connect. Relative paths are
+	// taken from the config directory.
+	UsersFile string `json:"users_file,omitempty"`
+}
+
+// Default returns the configuration used when no file exists
+func Default() *Config {
+	return &Config{
+		Store: StoreConfig{Backend: store.BackendJSON},
+		Drive: GoogleDriveConfig{
+			CredentialsFile: "credentials.json",
+			TokenFile:       "token.json",
+			Folder:          "todo backups",
+		},
+		Backup: BackupConfig{
+			Keep: BackupRetention{Last: 10, Daily: 7, Monthly: 12},
+		},
+		Server: ServerConfig{
+			Addr:      ":8080",
+			UsersFile: "users",
+		},
+	}
+}
+
+// ConfigDir returns $XDG_CONFIG_HOME/todo, defaulting to ~/.config/todo
+func ConfigDir() (string, error) {
+	return baseDir("XDG_CONFIG_HOME", ".config")
+}
+
+// DataDir returns $XDG_DATA_HOME/todo, defaulting to ~/.local/share/todo
+func DataDir() (string, error) {
+	return baseDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// StateDir returns $XDG_STATE_HOME/todo, defaulting to ~/.local/state/todo
+func StateDir() (string, error) {
+	return baseDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// baseDir resolves one XDG base directory. Relative values are ignored,
+// as the spec requires.
+func baseDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate home directory (set %s): %w", env, err)
+	}
+	return filepath.Join(home, fallback, appName), nil
+}
+
+// File returns the path of the configuration file
+func File() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// Load reads the configuration file and applies the TODO_FILE override.
+// A missing file yields the defaults.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path, err := File()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	if cfg.Store.Backend == "" {
+		cfg.Store.Backend = store.BackendJSON
+	}
+
+	if file := os.Getenv(FileEnv); file != "" {
+		if err := cfg.SetFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// SetFile points the store at a data file given on the command line or in
+// the environment. The path is taken relative to the working directory,
+// and a recognised extension also selects the backend.
+func (c *Config) SetFile(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	c.Store.Path = abs
+	if backend := store.BackendForPath(abs); backend != "" {
+		c.Store.Backend = backend
+	}
+	return nil
+}
+
+// StorePath returns the absolute path of the current list's data file
+func (c *Config) StorePath() (string, error) {
+	return c.ListPath(c.CurrentList())
+}
+
+// basePath returns the absolute path of the default list's data file
+func (c *Config) basePath() (string, error) {
+	path := c.Store.Path
+	if path == "" {
+		path = store.DefaultPath(c.Store.Backend)
+	}
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, path), nil
+}
+
+// OpenStore opens the current list, creating the data directory if
+// needed, and reports the data file it uses
+func (c *Config) OpenStore() (store.Store, string, error) {
+	return c.OpenList(c.CurrentList())
+}
+
+// CredentialsFile returns the absolute path of the Google OAuth client file
+func (c *Config) CredentialsFile() (string, error) {
+	return inConfigDir(c.Drive.CredentialsFile)
+}
+
+// TokenFile returns the absolute path of the cached Google OAuth token
+func (c *Config) TokenFile() (string, error) {
+	return inConfigDir(c.Drive.TokenFile)
+}
+
+// UsersFile returns the absolute path of the server's users file
+func (c *Config) UsersFile() (string, error) {
+	return inConfigDir(c.Server.UsersFile)
+}
+
+func inConfigDir(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, path), nil
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Move is one file to relocate from the working directory
+type Move struct {
+	From string
+	To   string
+}
+
+// legacyData and legacyConfig are the files older releases kept in the
+// working directory, mapped to their new names
+var (
+	legacyData = []string{
+		"todos.json", "todos.json.bak", "todos.json.v1.bak",
+		"todos.db", "todos.bolt",
+	}
+	legacyConfig = map[string]string{
+		"todo-config.json": "config.json",
+		"credentials.json": "credentials.json",
+		"token.json":       "token.json",
+	}
+)
+
+// LegacyMoves lists files in dir left by releases that kept everything in
+// the working directory and that have no counterpart in the XDG locations
+// yet. Nothing is returned when dir already is one of those locations.
+func LegacyMoves(dir string) ([]Move, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+	configDir, err := ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var moves []Move
+	add := func(name, to string) {
+		from := filepath.Join(dir, name)
+		if sameFile(from, to) {
+			return
+		}
+		if _, err := os.Stat(from); err != nil {
+			return
+		}
+		if _, err := os.Stat(to); err == nil {
+			return
+		}
+		moves = append(moves, Move{From: from, To: to})
+	}
+
+	for _, name := range legacyData {
+		add(name, filepath.Join(dataDir, name))
+	}
+	for name, newName := range legacyConfig {
+		add(name, filepath.Join(configDir, newName))
+	}
+	return moves, nil
+}
+
+// LegacyDataFile returns the todos.json in dir when it should be moved
+// into the data directory: it exists and the configured data file does
+// not. The empty string means there is nothing to offer.
+func (c *Config) LegacyDataFile(dir string) string {
+	path, err := c.basePath()
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(path); err == nil {
+		return ""
+	}
+
+	legacy := filepath.Join(dir, "todos.json")
+	if sameFile(legacy, path) {
+		return ""
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return ""
+	}
+	return legacy
+}
+
+// ApplyMoves moves each file into place, creating directories as needed.
+// Credentials keep their restrictive permissions since rename preserves
+// the mode; copies across filesystems reuse the source mode too.
+func ApplyMoves(moves []Move) error {
+	for _, m := range moves {
+		if err := os.MkdirAll(filepath.Dir(m.To), 0700); err != nil {
+			return err
+		}
+		if err := moveFile(m.From, m.To); err != nil {
+			return fmt.Errorf("moving %s to %s: %w", m.From, m.To, err)
+		}
+	}
+	return nil
+}
+
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	// Rename fails across filesystems, so fall back to copy and remove
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(to)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(to)
+		return err
+	}
+	src.Close()
+	return os.Remove(from)
+}
+
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"todo-bubbletea/store"
+)
+
+// DefaultList names the list kept in the main data file
+const DefaultList = "default"
+
+// listNamePattern limits list names to characters that are safe in file names
+var listNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidateListName reports whether name can be used for a list
+func ValidateListName(name string) error {
+	if !listNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid list name %q: use letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// CurrentList returns the list commands operate on
+func (c *Config) CurrentList() string {
+	if c.List == "" {
+		return DefaultList
+	}
+	return c.List
+}
+
+// ListPath returns the data file of a named list. The default list uses
+// the main data file; every other list sits next to it with the list name
+// appended, e.g. todos-work.json.
+func (c *Config) ListPath(name string) (string, error) {
+	base, err := c.basePath()
+	if err != nil {
+		return "", err
+	}
+	if name == "" || name == DefaultList {
+		return base, nil
+	}
+	if err := ValidateListName(name); err != nil {
+		return "", err
+	}
+
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-" + name + ext, nil
+}
+
+// Lists returns the names of every list with a data file, plus the default
+// and current lists, with the default list first
+func (c *Config) Lists() ([]string, error) {
+	base, err := c.basePath()
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{DefaultList: true, c.CurrentList(): true}
+	var names []string
+	for _, match := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(match, prefix), ext)
+		if ValidateListName(name) == nil && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if c.CurrentList() != DefaultList {
+		names = append(names, c.CurrentList())
+	}
+	sort.Strings(names)
+
+	return append([]string{DefaultList}, names...), nil
+}
+
+// OpenList opens the store holding a named list, recording its changes in
+// the list's outbox if it has remotes to sync with
+func (c *Config) OpenList(name string) (store.Store, string, error) {
+	path, err := c.ListPath(name)
+	if err != nil {
+		return nil, "", err
+	}
+	st, err := c.OpenRecorded(c.Store.Backend, path)
+	return st, path, err
+}
+
+// ListExists reports whether a list has a data file yet
+func (c *Config) ListExists(name string) bool {
+	path, err := c.ListPath(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"todo-bubbletea/todo"
+)
+
+// Environment variables read for network credentials
+const (
+	// PasswordEnv holds the password for basic auth
+	PasswordEnv = "TODO_PASSWORD"
+	// TokenEnv holds a bearer token
+	TokenEnv = "TODO_TOKEN"
+)
+
+// Auth schemes for Remote.Auth
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+)
+
+// Remote is a todo server saved under a name with 'todo remote add'. The
+// password or token itself is never stored; it comes from the environment,
+// PasswordCommand or a prompt.
+type Remote struct {
+	URL  string `json:"url"`
+	User string `json:"user,omitempty"`
+	// Auth is AuthBasic, the default, or AuthBearer to send the secret as
+	// a bearer token
+	Auth string `json:"auth,omitempty"`
+	// PasswordEnv names an environment variable holding the password or
+	// token
+	PasswordEnv string `json:"password_env,omitempty"`
+	// PasswordCommand is run by the shell and the first line it prints is
+	// the password or token, e.g. "pass show todo/team"
+	PasswordCommand string `json:"password_command,omitempty"`
+
+	// CACert is a PEM bundle of certificate authorities trusted for the
+	// server besides the system's, for servers with a private CA
+	CACert string `json:"ca_cert,omitempty"`
+	// ClientCert and ClientKey are a PEM certificate and key presented to
+	// servers that require mutual TLS
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	// Insecure skips checking the server's certificate
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// Bearer reports whether the remote takes a bearer token
+func (r Remote) Bearer() bool {
+	return r.Auth == AuthBearer
+}
+
+// SecretEnv names the environment variable holding the password or token
+// of remotes that do not say where theirs is
+func (r Remote) SecretEnv() string {
+	if r.Bearer() {
+		return TokenEnv
+	}
+	return PasswordEnv
+}
+
+// Secret returns the remote's password or token, or "" if it has none. A
+// remote with a PasswordEnv or PasswordCommand only reads those, in that
+// order, so that a password set in SecretEnv for one server is never sent
+// to another that keeps its own elsewhere. Other remotes read SecretEnv.
+func (r Remote) Secret() (string, error) {
+	if r.PasswordEnv != "" || r.PasswordCommand != "" {
+		return lookupSecret([]string{r.PasswordEnv}, r.PasswordCommand)
+	}
+	return lookupSecret([]string{r.SecretEnv()}, "")
+}
+
+// lookupSecret returns the first of the environment variables envs that is
+// set, or else the output of command, or "" if there is no command
+func lookupSecret(envs []string, command string) (string, error) {
+	for _, env := range envs {
+		if env == "" {
+			continue
+		}
+		if secret := os.Getenv(env); secret != "" {
+			return secret, nil
+		}
+	}
+	if command != "" {
+		return runPasswordCommand(command)
+	}
+	return "", nil
+}
+
+// runPasswordCommand runs a credential helper and returns the first line
+// it prints
+func runPasswordCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("password command %q: %w", command, err)
+	}
+
+	line, _, _ := bytes.Cut(out, []byte("\n"))
+	secret := strings.TrimRight(string(line), "\r")
+	if secret == "" {
+		return "", fmt.Errorf("password command %q printed nothing", command)
+	}
+	return secret, nil
+}
+
+// TLSConfig returns the TLS settings for the remote, or nil when it has
+// none and the defaults apply. Relative paths are taken from the config
+// directory.
+func (r Remote) TLSConfig() (*tls.Config, error) {
+	if r.CACert == "" && r.ClientCert == "" && r.ClientKey == "" && !r.Insecure {
+		return nil, nil
+	}
+	conf := &tls.Config{InsecureSkipVerify: r.Insecure}
+
+	if r.CACert != "" {
+		path, err := inConfigDir(r.CACert)
+		if err != nil {
+			return nil, err
+		}
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in CA bundle %s", path)
+		}
+		conf.RootCAs = pool
+	}
+
+	if r.ClientCert != "" || r.ClientKey != "" {
+		if r.ClientCert == "" || r.ClientKey == "" {
+			return nil, fmt.Errorf("a client certificate needs both client_cert and client_key")
+		}
+		certFile, err := inConfigDir(r.ClientCert)
+		if err != nil {
+			return nil, err
+		}
+		keyFile, err := inConfigDir(r.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
+
+var remoteNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidateRemoteName reports whether name can be used for a remote
+func ValidateRemoteName(name string) error {
+	if !remoteNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid remote name %q: use letters, digits, '.', '-' and '_'", name)
+	}
+	return nil
+}
+
+// DefaultRemoteName returns the remote used when a network command is
+// given none, or "" if there is no default
+func (c *Config) DefaultRemoteName() string {
+	if c.Remote != "" {
+		return c.Remote
+	}
+	if len(c.Remotes) == 1 {
+		for name := range c.Remotes {
+			return name
+		}
+	}
+	return ""
+}
+
+// Set writes one top-level setting to the configuration file, leaving the
+// rest of the file as it is. A nil value removes the setting.
+func Set(key string, value any) error {
+	path, err := File()
+	if err != nil {
+		return err
+	}
+
+	doc := map[string]json.RawMessage{}
+	mode := os.FileMode(0644)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if value == nil {
+		delete(doc, key)
+	} else {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		doc[key] = raw
+	}
+
+	data, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return todo.WriteFileAtomic(path, append(data, '\n'), mode)
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestRemoteSecret(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("password commands run under sh")
+	}
+	t.Setenv(PasswordEnv, "global")
+	t.Setenv(TokenEnv, "global token")
+	t.Setenv("TEAM_PASSWORD", "team")
+
+	tests := []struct {
+		name   string
+		remote Remote
+		want   string
+	}{
+		{"own variable", Remote{User: "alice", PasswordEnv: "TEAM_PASSWORD"}, "team"},
+		{"own command", Remote{User: "alice", PasswordCommand: "echo helper"}, "helper"},
+		{"own variable before command", Remote{User: "alice", PasswordEnv: "TEAM_PASSWORD", PasswordCommand: "echo helper"}, "team"},
+		{"own variable unset", Remote{User: "alice", PasswordEnv: "UNSET_PASSWORD"}, ""},
+		{"own token command", Remote{Auth: AuthBearer, PasswordCommand: "echo helper"}, "helper"},
+		{"no source of its own", Remote{User: "alice"}, "global"},
+		{"token with no source of its own", Remote{Auth: AuthBearer}, "global token"},
+	}
+	for _, tt := range tests {
+		got, err := tt.remote.Secret()
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Secret = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
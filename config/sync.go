@@ -0,0 +1,136 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-bubbletea/outbox"
+	"todo-bubbletea/store"
+)
+
+// DefaultTombstoneAge is how long deleted todos are kept for sync when the
+// config file does not say
+const DefaultTombstoneAge = "30d"
+
+// DefaultSyncInterval is how often the TUI syncs with the default remote
+// when the config file does not say
+const DefaultSyncInterval = time.Minute
+
+// SyncConfig holds sync settings
+type SyncConfig struct {
+	// TombstoneAge is how long 'todo gc' keeps deleted todos, such as
+	// "30d" or "72h". Machines that sync less often than this may bring
+	// purged todos back.
+	TombstoneAge string `json:"tombstone_age,omitempty"`
+	// Interval is how often the TUI syncs with the default remote, such
+	// as "5m"; "0" turns that off
+	Interval string `json:"interval,omitempty"`
+}
+
+// SyncInterval returns how often the TUI syncs, or 0 if it does not
+func (c *Config) SyncInterval() (time.Duration, error) {
+	if c.Sync.Interval == "" {
+		return DefaultSyncInterval, nil
+	}
+	d, err := time.ParseDuration(c.Sync.Interval)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("sync.interval: invalid interval %q (use e.g. 5m, or 0 for none)", c.Sync.Interval)
+	}
+	return d, nil
+}
+
+// TombstoneAge returns the configured tombstone age
+func (c *Config) TombstoneAge() (time.Duration, error) {
+	age := c.Sync.TombstoneAge
+	if age == "" {
+		age = DefaultTombstoneAge
+	}
+	d, err := ParseAge(age)
+	if err != nil {
+		return 0, fmt.Errorf("sync.tombstone_age: %w", err)
+	}
+	return d, nil
+}
+
+// ParseAge parses a duration as accepted by time.ParseDuration, or a whole
+// number of days such as "30d"
+func ParseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d or 72h)", s)
+	}
+	return d, nil
+}
+
+// SyncBasePath returns where the copy of the current list saved after its
+// last sync with remote is kept. Each pair of data file and remote has its
+// own base, under $XDG_STATE_HOME/todo/sync.
+func (c *Config) SyncBasePath(remote string) (string, error) {
+	path, err := c.StorePath()
+	if err != nil {
+		return "", err
+	}
+	return syncBasePath(path, remote)
+}
+
+// syncBasePath returns where the copy of the data file at storePath saved
+// after its last sync with remote is kept
+func syncBasePath(storePath, remote string) (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(storePath + "\x00" + remote))
+	return filepath.Join(dir, "sync", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// OutboxPath returns where the outbox of the data file at storePath is
+// kept, under $XDG_STATE_HOME/todo/outbox
+func OutboxPath(storePath string) (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(storePath))
+	return filepath.Join(dir, "outbox", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// OpenRecorded opens the store at path with the given backend. When the
+// config names remotes to sync with, every change made through it is
+// recorded in the file's outbox for sync to replay to them; otherwise
+// there is nothing to replay to and it is opened as it is.
+func (c *Config) OpenRecorded(backend, path string) (store.Store, error) {
+	if len(c.Remotes) == 0 {
+		return store.Open(backend, path)
+	}
+	box, err := OutboxPath(path)
+	if err != nil {
+		return nil, err
+	}
+	// The outbox knows each remote by the sync base it keeps for the file
+	var bases []string
+	for _, remote := range c.Remotes {
+		base, err := syncBasePath(path, remote.URL)
+		if err != nil {
+			return nil, err
+		}
+		bases = append(bases, base)
+	}
+	st, err := store.Open(backend, path)
+	if err != nil {
+		return nil, err
+	}
+	return outbox.Wrap(st, outbox.Open(box, bases...)), nil
+}
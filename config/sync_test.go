@@ -0,0 +1,30 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"todo-bubbletea/outbox"
+	"todo-bubbletea/store"
+)
+
+func TestOpenListRecordsOnlyWithRemotes(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	c := Default()
+	if err := c.SetFile(filepath.Join(t.TempDir(), "todos.json")); err != nil {
+		t.Fatal(err)
+	}
+	c.Store.Backend = store.BackendJSON
+
+	for _, remotes := range []map[string]Remote{nil, {"team": {URL: "https://todos.example.com"}}} {
+		c.Remotes = remotes
+		st, _, err := c.OpenList(DefaultList)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recorded := outbox.Of(st) != nil; recorded != (remotes != nil) {
+			t.Errorf("with remotes %v: recorded = %v", remotes, recorded)
+		}
+		st.Close()
+	}
+}
@@ -0,0 +1,170 @@
+// Package gdrive keeps todo backups in Google Drive. It signs in with
+// OAuth through a loopback redirect, as Google expects of desktop apps,
+// and keeps the resulting token in a file.
+package gdrive
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v3"
+
+	"todo-bubbletea/todo"
+)
+
+// Scope is the access asked for: only files this app created
+const Scope = drive.DriveFileScope
+
+// ErrNotLoggedIn is returned when there is no saved token
+var ErrNotLoggedIn = errors.New("not logged in to Google Drive")
+
+// OAuthConfig reads the OAuth client downloaded from the Google Cloud
+// console, which must be of the "Desktop app" type
+func OAuthConfig(credentialsFile string) (*oauth2.Config, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no Google OAuth client at %s: create a Desktop app client in the Google Cloud console and save its JSON there", credentialsFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conf, err := google.ConfigFromJSON(data, Scope)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", credentialsFile, err)
+	}
+	return conf, nil
+}
+
+// Login runs the OAuth authorization code flow with PKCE. It listens for
+// the redirect on a random loopback port, hands the URL the user has to
+// visit to show, and waits for the browser to come back with a code whose
+// state matches, or for ctx to end. Redirects with any other state are
+// turned away without ending the wait, so a stray or forged request cannot
+// abort the sign-in.
+func Login(ctx context.Context, conf *oauth2.Config, show func(authURL string)) (*oauth2.Token, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listening for the OAuth redirect: %w", err)
+	}
+	defer ln.Close()
+
+	loopback := *conf
+	loopback.RedirectURL = fmt.Sprintf("http://%s/", ln.Addr())
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var res result
+		switch {
+		case r.URL.Path != "/":
+			http.NotFound(w, r)
+			return
+		case query.Get("state") != state:
+			// Not the answer to our request; possibly forged
+			http.Error(w, "OAuth redirect has the wrong state", http.StatusBadRequest)
+			return
+		case query.Get("error") != "":
+			res.err = fmt.Errorf("authorization refused: %s", query.Get("error"))
+		case query.Get("code") == "":
+			res.err = errors.New("OAuth redirect has no code")
+		default:
+			res.code = query.Get("code")
+		}
+
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Signed in to Google Drive. You can close this window and return to the terminal.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	show(loopback.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier)))
+
+	var res result
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for Google sign-in: %w", ctx.Err())
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	tok, err := loopback.Exchange(ctx, res.code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchanging the authorization code: %w", err)
+	}
+	return tok, nil
+}
+
+// randomState returns an unguessable value tying the redirect to this
+// login
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// LoadToken reads the token saved by SaveToken, returning ErrNotLoggedIn
+// if there is none
+func LoadToken(path string) (*oauth2.Token, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotLoggedIn
+	}
+	if err != nil {
+		return nil, err
+	}
+	tok := &oauth2.Token{}
+	if err := json.Unmarshal(data, tok); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return tok, nil
+}
+
+// SaveToken writes tok to path, readable only by the user
+func SaveToken(path string, tok *oauth2.Token) error {
+	data, err := json.MarshalIndent(tok, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return todo.WriteFileAtomic(path, append(data, '\n'), 0600)
+}
+
+// Logout deletes the saved token. It reports whether there was one.
+func Logout(path string) (bool, error) {
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,213 @@
+package gdrive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// fakeOAuth is an authorization server that grants one code to whoever
+// asks and hands out a token for it if the PKCE verifier matches
+type fakeOAuth struct {
+	*httptest.Server
+	challenge string
+}
+
+func newFakeOAuth(t *testing.T) *fakeOAuth {
+	t.Helper()
+	f := &fakeOAuth{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if r.Form.Get("code") != "the-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != f.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access",
+			"refresh_token": "refresh",
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+		})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeOAuth) config() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID: "client",
+		Endpoint: oauth2.Endpoint{AuthURL: f.URL + "/auth", TokenURL: f.URL + "/token"},
+		Scopes:   []string{Scope},
+	}
+}
+
+// browser plays the user's browser: it checks the auth URL, notes the
+// PKCE challenge and follows the redirect with params, where a "state" of
+// "" means the one from the auth URL
+func (f *fakeOAuth) browser(t *testing.T, params url.Values) func(string) {
+	return func(authURL string) {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			t.Errorf("auth URL: %v", err)
+			return
+		}
+		q := u.Query()
+		if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+			t.Errorf("auth URL has no S256 PKCE challenge: %s", authURL)
+		}
+		redirect := q.Get("redirect_uri")
+		if !strings.HasPrefix(redirect, "http://127.0.0.1:") {
+			t.Errorf("redirect %q is not a loopback address", redirect)
+		}
+		f.challenge = q.Get("code_challenge")
+
+		if !params.Has("state") {
+			params.Set("state", q.Get("state"))
+		}
+		go func() {
+			resp, err := http.Get(redirect + "?" + params.Encode())
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+}
+
+func login(t *testing.T, f *fakeOAuth, params url.Values) (*oauth2.Token, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return Login(ctx, f.config(), f.browser(t, params))
+}
+
+func TestLogin(t *testing.T) {
+	f := newFakeOAuth(t)
+	tok, err := login(t, f, url.Values{"code": {"the-code"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "access" || tok.RefreshToken != "refresh" {
+		t.Errorf("got token %+v", tok)
+	}
+}
+
+func TestLoginIgnoresWrongState(t *testing.T) {
+	f := newFakeOAuth(t)
+	show := f.browser(t, url.Values{"code": {"the-code"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tok, err := Login(ctx, f.config(), func(authURL string) {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			t.Errorf("auth URL: %v", err)
+			return
+		}
+		// A forged redirect arrives before the real one
+		forged := url.Values{"code": {"forged-code"}, "state": {"forged"}}
+		resp, err := http.Get(u.Query().Get("redirect_uri") + "?" + forged.Encode())
+		if err != nil {
+			t.Errorf("forged redirect: %v", err)
+		} else {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("forged redirect got %s, want 400", resp.Status)
+			}
+		}
+		show(authURL)
+	})
+	if err != nil {
+		t.Fatalf("login after a forged redirect: %v", err)
+	}
+	if tok.AccessToken != "access" {
+		t.Errorf("got token %+v", tok)
+	}
+}
+
+func TestLoginWrongStateOnly(t *testing.T) {
+	f := newFakeOAuth(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := Login(ctx, f.config(), f.browser(t, url.Values{"code": {"the-code"}, "state": {"forged"}}))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want to keep waiting until the deadline", err)
+	}
+}
+
+func TestLoginRefused(t *testing.T) {
+	f := newFakeOAuth(t)
+	_, err := login(t, f, url.Values{"error": {"access_denied"}})
+	if err == nil || !strings.Contains(err.Error(), "access_denied") {
+		t.Fatalf("got %v, want access_denied", err)
+	}
+}
+
+func TestLoginBadVerifier(t *testing.T) {
+	f := newFakeOAuth(t)
+	show := f.browser(t, url.Values{"code": {"the-code"}})
+	_, err := Login(context.Background(), f.config(), func(authURL string) {
+		show(authURL)
+		// As if someone else's challenge had been sent
+		f.challenge = "something-else"
+	})
+	if err == nil {
+		t.Fatal("login succeeded with a mismatched PKCE verifier")
+	}
+}
+
+func TestLoginTimeout(t *testing.T) {
+	f := newFakeOAuth(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := Login(ctx, f.config(), func(string) {}); err == nil {
+		t.Fatal("login without a redirect succeeded")
+	}
+}
+
+func TestTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo", "token.json")
+	if _, err := LoadToken(path); err != ErrNotLoggedIn {
+		t.Fatalf("LoadToken with no file: got %v, want ErrNotLoggedIn", err)
+	}
+
+	want := &oauth2.Token{AccessToken: "a", RefreshToken: "r", Expiry: time.Now().Add(time.Hour).Round(time.Second)}
+	if err := SaveToken(path, want); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token file mode %o, want 600", perm)
+	}
+	got, err := LoadToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AccessToken != want.AccessToken || got.RefreshToken != want.RefreshToken || !got.Expiry.Equal(want.Expiry) {
+		t.Errorf("LoadToken = %+v, want %+v", got, want)
+	}
+
+	if ok, err := Logout(path); !ok || err != nil {
+		t.Fatalf("Logout = %v, %v", ok, err)
+	}
+	if ok, err := Logout(path); ok || err != nil {
+		t.Fatalf("second Logout = %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,177 @@
+package gdrive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+
+	"todo-bubbletea/backup"
+)
+
+// Snapshots record what they hold in these app properties, which the
+// Drive UI does not show but queries can match
+const (
+	listProperty  = "todo_list"
+	takenProperty = "todo_taken"
+	countProperty = "todo_count"
+)
+
+const folderMimeType = "application/vnd.google-apps.folder"
+
+var _ backup.Remote = (*Backups)(nil)
+
+// Backups are the snapshots kept in one Drive folder. It is the
+// backup.Remote for Google Drive.
+type Backups struct {
+	service  *drive.Service
+	folder   string
+	folderID string // empty until the folder exists
+}
+
+// OpenBackups looks for the folder named folder at the top of the Drive.
+// It is only created when the first backup is uploaded.
+func OpenBackups(service *drive.Service, folder string) (*Backups, error) {
+	b := &Backups{service: service, folder: folder}
+	r, err := service.Files.List().
+		Q(fmt.Sprintf("name = %s and mimeType = '%s' and 'root' in parents and trashed = false", quote(folder), folderMimeType)).
+		Fields("files(id)").
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("looking for folder %q: %w", folder, err)
+	}
+	if len(r.Files) > 0 {
+		b.folderID = r.Files[0].Id
+	}
+	return b, nil
+}
+
+// Put uploads data as a new file in the folder, creating the folder if
+// need be
+func (b *Backups) Put(v backup.Version, data []byte) (backup.Version, error) {
+	if b.folderID == "" {
+		folder, err := b.service.Files.Create(&drive.File{Name: b.folder, MimeType: folderMimeType}).Fields("id").Do()
+		if err != nil {
+			return backup.Version{}, fmt.Errorf("creating folder %q: %w", b.folder, err)
+		}
+		b.folderID = folder.Id
+	}
+
+	taken := v.Time.UTC()
+	file := &drive.File{
+		Name:     fmt.Sprintf("%s %s.json", v.List, taken.Format("2006-01-02T150405Z")),
+		Parents:  []string{b.folderID},
+		MimeType: "application/json",
+		AppProperties: map[string]string{
+			listProperty:  v.List,
+			takenProperty: taken.Format(time.RFC3339Nano),
+			countProperty: strconv.Itoa(v.Todos),
+		},
+	}
+	created, err := b.service.Files.Create(file).Media(bytes.NewReader(data)).Fields(backupFields).Do()
+	if err != nil {
+		return backup.Version{}, err
+	}
+	return versionOf(created), nil
+}
+
+// backupFields are the file fields versionOf reads
+const backupFields = "id, name, size, createdTime, appProperties"
+
+// List returns the backups of list, newest first
+func (b *Backups) List(list string) ([]backup.Version, error) {
+	if b.folderID == "" {
+		return nil, nil
+	}
+	var backups []backup.Version
+	err := b.service.Files.List().
+		Q(fmt.Sprintf("%s in parents and appProperties has { key = '%s' and value = %s } and trashed = false",
+			quote(b.folderID), listProperty, quote(list))).
+		Fields("nextPageToken, files("+backupFields+")").
+		PageSize(1000).
+		Pages(context.Background(), func(r *drive.FileList) error {
+			for _, f := range r.Files {
+				backups = append(backups, versionOf(f))
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// Get downloads the contents of a backup
+func (b *Backups) Get(v backup.Version) ([]byte, error) {
+	resp, err := b.service.Files.Get(v.ID).Download()
+	if err != nil {
+		return nil, notFound(err, v)
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Delete removes a backup for good, bypassing the trash
+func (b *Backups) Delete(v backup.Version) error {
+	return notFound(b.service.Files.Delete(v.ID).Do(), v)
+}
+
+// notFound turns Drive's 404 for the file of v into backup.ErrNotFound
+func notFound(err error, v backup.Version) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", backup.ErrNotFound, v.ID)
+	}
+	return err
+}
+
+func (b *Backups) String() string {
+	return fmt.Sprintf("'%s' in Google Drive", b.folder)
+}
+
+// LegacyFile is the single backup older versions overwrote on every
+// upload
+const LegacyFile = "todos-backup.json"
+
+// Legacy returns the contents of LegacyFile, or nil if there is none
+func (b *Backups) Legacy() ([]byte, error) {
+	r, err := b.service.Files.List().
+		Q(fmt.Sprintf("name = %s and trashed = false", quote(LegacyFile))).
+		Fields("files(id)").
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("looking for %s: %w", LegacyFile, err)
+	}
+	if len(r.Files) == 0 {
+		return nil, nil
+	}
+	return b.Get(backup.Version{ID: r.Files[0].Id})
+}
+
+func versionOf(f *drive.File) backup.Version {
+	v := backup.Version{ID: f.Id, Name: f.Name, List: f.AppProperties[listProperty], Todos: -1, Size: f.Size}
+	if t, err := time.Parse(time.RFC3339Nano, f.AppProperties[takenProperty]); err == nil {
+		v.Time = t
+	} else if t, err := time.Parse(time.RFC3339, f.CreatedTime); err == nil {
+		v.Time = t
+	}
+	if n, err := strconv.Atoi(f.AppProperties[countProperty]); err == nil {
+		v.Todos = n
+	}
+	return v
+}
+
+// quote makes s a string literal for a Drive query
+func quote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
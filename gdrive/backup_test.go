@@ -0,0 +1,37 @@
+package gdrive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+
+	"todo-bubbletea/backup"
+)
+
+func TestMissingBackup(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"error": {"code": 404, "message": "File not found: gone."}}`)
+	}))
+	defer ts.Close()
+	service, err := drive.NewService(context.Background(), option.WithEndpoint(ts.URL+"/"), option.WithHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Backups{service: service, folder: "todo", folderID: "folder"}
+
+	gone := backup.Version{ID: "gone"}
+	if err := b.Delete(gone); !errors.Is(err, backup.ErrNotFound) {
+		t.Errorf("Delete of a missing backup: %v, want ErrNotFound", err)
+	}
+	if _, err := b.Get(gone); !errors.Is(err, backup.ErrNotFound) {
+		t.Errorf("Get of a missing backup: %v, want ErrNotFound", err)
+	}
+}
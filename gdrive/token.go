@@ -0,0 +1,70 @@
+package gdrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrSignInExpired is returned when the saved token can no longer be
+// refreshed, because the refresh token was revoked, has expired or was
+// never given, and the user has to sign in again
+var ErrSignInExpired = errors.New("sign-in to Google Drive has expired or been revoked")
+
+// TokenSource returns a token source for conf that starts from tok and
+// refreshes it as needed, saving each new token to path so the next run
+// starts from it. Tokens that cannot be refreshed give ErrSignInExpired.
+func TokenSource(ctx context.Context, conf *oauth2.Config, tok *oauth2.Token, path string) oauth2.TokenSource {
+	return &savingSource{
+		base: conf.TokenSource(ctx, tok),
+		path: path,
+		last: tok,
+	}
+}
+
+// Client returns an HTTP client authorized by TokenSource
+func Client(ctx context.Context, conf *oauth2.Config, tok *oauth2.Token, path string) *http.Client {
+	return oauth2.NewClient(ctx, TokenSource(ctx, conf, tok, path))
+}
+
+// savingSource writes every token its base hands out that differs from
+// the last one it saw
+type savingSource struct {
+	base oauth2.TokenSource
+	path string
+
+	mu   sync.Mutex
+	last *oauth2.Token
+}
+
+func (s *savingSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tok, err := s.base.Token()
+	if err != nil {
+		if s.last.RefreshToken == "" || invalidGrant(err) {
+			return nil, fmt.Errorf("%w: %w", ErrSignInExpired, err)
+		}
+		return nil, err
+	}
+	if tok.AccessToken == s.last.AccessToken && tok.RefreshToken == s.last.RefreshToken {
+		return tok, nil
+	}
+	if err := SaveToken(s.path, tok); err != nil {
+		return nil, fmt.Errorf("saving refreshed Google Drive token: %w", err)
+	}
+	s.last = tok
+	return tok, nil
+}
+
+// invalidGrant reports whether the authorization server refused to refresh
+// the token, as opposed to failing to answer
+func invalidGrant(err error) bool {
+	var retrieve *oauth2.RetrieveError
+	return errors.As(err, &retrieve) && retrieve.ErrorCode == "invalid_grant"
+}
@@ -0,0 +1,90 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newRefreshServer is an authorization server that refreshes the token
+// "refresh" and refuses any other, and an API behind it that wants the
+// refreshed access token
+func newRefreshServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("refresh_token") != "refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "Token has been expired or revoked."})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "fresh",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+	mux.HandleFunc("GET /api", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientSavesRefreshedToken(t *testing.T) {
+	srv := newRefreshServer(t)
+	conf := &oauth2.Config{ClientID: "client", Endpoint: oauth2.Endpoint{TokenURL: srv.URL + "/token"}}
+	path := filepath.Join(t.TempDir(), "token.json")
+	stale := &oauth2.Token{AccessToken: "stale", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}
+	if err := SaveToken(path, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := Client(context.Background(), conf, stale, path).Get(srv.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("API answered %s", resp.Status)
+	}
+
+	saved, err := LoadToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.AccessToken != "fresh" || saved.RefreshToken != "refresh" {
+		t.Errorf("saved token %+v, want the refreshed one keeping its refresh token", saved)
+	}
+}
+
+func TestClientSignInExpired(t *testing.T) {
+	srv := newRefreshServer(t)
+	conf := &oauth2.Config{ClientID: "client", Endpoint: oauth2.Endpoint{TokenURL: srv.URL + "/token"}}
+	path := filepath.Join(t.TempDir(), "token.json")
+
+	for name, tok := range map[string]*oauth2.Token{
+		"revoked":    {AccessToken: "stale", RefreshToken: "revoked", Expiry: time.Now().Add(-time.Hour)},
+		"no refresh": {AccessToken: "stale", Expiry: time.Now().Add(-time.Hour)},
+	} {
+		_, err := Client(context.Background(), conf, tok, path).Get(srv.URL + "/api")
+		if !errors.Is(err, ErrSignInExpired) {
+			t.Errorf("%s: got %v, want ErrSignInExpired", name, err)
+		}
+	}
+	if _, err := LoadToken(path); err != ErrNotLoggedIn {
+		t.Errorf("a token was saved after failed refreshes: %v", err)
+	}
+}
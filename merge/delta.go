@@ -0,0 +1,106 @@
+package merge
+
+import "todo-bubbletea/todo"
+
+// Apply returns base with changes laid over it: each change replaces the
+// todo with the same UUID, or is added if base has none. It rebuilds the
+// other side's list from the base and the todos changed there since.
+func Apply(base *todo.TodoList, changes []todo.Todo) *todo.TodoList {
+	list := todo.NewTodoList()
+	if base != nil {
+		list.NextID = base.NextID
+		list.Todos = append(list.Todos, base.Todos...)
+	}
+
+	index := make(map[string]int, len(list.Todos))
+	for i := range list.Todos {
+		list.Todos[i].EnsureUUID()
+		index[list.Todos[i].UUID] = i
+	}
+	for _, t := range changes {
+		t.EnsureUUID()
+		if i, ok := index[t.UUID]; ok {
+			// The base holds the local ID, which Merge keeps anyway
+			t.ID = list.Todos[i].ID
+			list.Todos[i] = t
+			continue
+		}
+		index[t.UUID] = len(list.Todos)
+		list.Todos = append(list.Todos, t)
+	}
+
+	for _, t := range list.Todos {
+		if t.ID >= list.NextID {
+			list.NextID = t.ID + 1
+		}
+	}
+	return list
+}
+
+// Changes returns the todos in to that from lacks, or that differ from
+// their copy in from in a merged field or in being deleted. They are what
+// has to be sent for from to become to.
+func Changes(from, to *todo.TodoList) []todo.Todo {
+	var old map[string]todo.Todo
+	if from != nil {
+		old = byUUID(from.Todos)
+	}
+
+	changes := []todo.Todo{}
+	for _, t := range to.Todos {
+		t.EnsureUUID()
+		prev, ok := old[t.UUID]
+		if !ok || changed(prev, t) || prev.Deleted() != t.Deleted() {
+			changes = append(changes, t)
+		}
+	}
+	return changes
+}
+
+// Kinds of Pending change
+const (
+	Added   = "added"
+	Edited  = "edited"
+	Deleted = "deleted"
+)
+
+// Pending is a change made on one side since the base, waiting to be sent
+// to the other
+type Pending struct {
+	Todo todo.Todo
+	// Change is Added, Edited or Deleted
+	Change string
+	// Fields names the fields an edit changed
+	Fields []string
+	// Note says more about the change, such as the list a todo moved to
+	Note string
+}
+
+// Diff returns the differences between base and list, in list order. With
+// a nil base every todo in list is new. Todos added and deleted again since
+// base are left out, and so is everything in between: Diff sees only the
+// two ends, not the changes that led from one to the other.
+func Diff(base, list *todo.TodoList) []Pending {
+	var old map[string]todo.Todo
+	if base != nil {
+		old = byUUID(base.Todos)
+	}
+
+	var pending []Pending
+	for _, t := range list.Todos {
+		t.EnsureUUID()
+		prev, ok := old[t.UUID]
+		switch {
+		case !ok && !t.Deleted():
+			pending = append(pending, Pending{Todo: t, Change: Added})
+		case !ok:
+		case t.Deleted() && !prev.Deleted():
+			pending = append(pending, Pending{Todo: t, Change: Deleted})
+		case !t.Deleted():
+			if names := ChangedFields(prev, t); len(names) > 0 || prev.Deleted() {
+				pending = append(pending, Pending{Todo: t, Change: Edited, Fields: names})
+			}
+		}
+	}
+	return pending
+}
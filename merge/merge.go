@@ -0,0 +1,402 @@
+// Package merge reconciles two copies of a todo list that have both
+// changed since they were last in sync. The copy saved after the last
+// successful sync is the common base: a field changed on only one side
+// takes that side's value, and a field changed differently on both sides is
+// a conflict for the caller to resolve.
+package merge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"todo-bubbletea/todo"
+)
+
+// ErrConflict is returned by Merge when both sides changed the same field
+// and no resolver was given
+var ErrConflict = errors.New("conflicting changes")
+
+// Side names one of the two copies being merged
+type Side int
+
+const (
+	Local Side = iota
+	Remote
+)
+
+func (s Side) String() string {
+	if s == Remote {
+		return "remote"
+	}
+	return "local"
+}
+
+// ParseSide parses "local" or "remote"
+func ParseSide(s string) (Side, error) {
+	switch strings.ToLower(s) {
+	case "local":
+		return Local, nil
+	case "remote":
+		return Remote, nil
+	}
+	return Local, fmt.Errorf("invalid side %q (want local or remote)", s)
+}
+
+// FieldDeleted is the Conflict.Field used when one side deleted a todo the
+// other side edited
+const FieldDeleted = "deleted"
+
+// Conflict is a field both sides changed to different values since the
+// base. Values are formatted for display; for FieldDeleted they read
+// "deleted" or "edited".
+type Conflict struct {
+	ID     int
+	UUID   string
+	Title  string
+	Field  string
+	Base   string
+	Local  string
+	Remote string
+}
+
+// Resolver picks the side whose value wins a conflict
+type Resolver func(Conflict) (Side, error)
+
+// Prefer returns a resolver that always picks side
+func Prefer(side Side) Resolver {
+	return func(Conflict) (Side, error) { return side, nil }
+}
+
+// Result is the outcome of a merge
+type Result struct {
+	// List is the merged list, ordered by ID
+	List *todo.TodoList
+	// Conflicts lists every conflict found, resolved or not
+	Conflicts []Conflict
+	// Renumbered maps the IDs of todos from remote that were taken locally
+	// to the IDs they were given
+	Renumbered map[int]int
+}
+
+// field is one mergeable part of a todo
+type field struct {
+	name string
+	get  func(todo.Todo) string
+	set  func(dst *todo.Todo, src todo.Todo)
+}
+
+// fields lists what is merged field by field. UUID and CreatedAt never
+// change and the ID is local; CompletedAt travels with the completion
+// status.
+var fields = []field{
+	{"title", func(t todo.Todo) string { return t.Title }, func(d *todo.Todo, s todo.Todo) { d.Title = s.Title }},
+	{"description", func(t todo.Todo) string { return t.Description }, func(d *todo.Todo, s todo.Todo) { d.Description = s.Description }},
+	{"status", status, func(d *todo.Todo, s todo.Todo) { d.Completed, d.CompletedAt = s.Completed, s.CompletedAt }},
+	{"priority", func(t todo.Todo) string { return t.Priority }, func(d *todo.Todo, s todo.Todo) { d.Priority = s.Priority }},
+	{"category", func(t todo.Todo) string { return t.Category }, func(d *todo.Todo, s todo.Todo) { d.Category = s.Category }},
+	{"due_date", dueDate, func(d *todo.Todo, s todo.Todo) { d.DueDate = s.DueDate }},
+}
+
+func status(t todo.Todo) string {
+	if t.Completed {
+		return "done"
+	}
+	return "pending"
+}
+
+func dueDate(t todo.Todo) string {
+	if t.DueDate == nil {
+		return ""
+	}
+	return t.DueDate.Format("2006-01-02")
+}
+
+// changed reports whether any merged field differs between a and b
+func changed(a, b todo.Todo) bool {
+	return len(ChangedFields(a, b)) > 0
+}
+
+// ChangedFields names the merged fields that differ between a and b
+func ChangedFields(a, b todo.Todo) []string {
+	var names []string
+	for _, f := range fields {
+		if f.get(a) != f.get(b) {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// Merge combines local and remote, which have both moved on from base. A
+// nil base means they have never been synced. Todos are matched by UUID;
+// local IDs never change, and a todo arriving from remote whose ID is
+// already taken here gets the next free one. Conflicts are passed to
+// resolve; when resolve is nil and there are conflicts, Merge returns
+// ErrConflict along with a Result listing them.
+func Merge(base, local, remote *todo.TodoList, resolve Resolver) (*Result, error) {
+	if base == nil {
+		base = todo.NewTodoList()
+	}
+	m := &merger{resolve: resolve, result: &Result{Renumbered: map[int]int{}}}
+
+	baseByUUID := byUUID(base.Todos)
+	localByUUID := byUUID(local.Todos)
+	remoteByUUID := byUUID(remote.Todos)
+
+	var merged, arrived []todo.Todo
+	for _, key := range allUUIDs(baseByUUID, localByUUID, remoteByUUID) {
+		b, inBase := baseByUUID[key]
+		l, inLocal := localByUUID[key]
+		r, inRemote := remoteByUUID[key]
+
+		switch {
+		case inLocal && inRemote:
+			t, err := m.mergeTodo(b, inBase, l, r)
+			if err != nil {
+				return nil, err
+			}
+			t.ID = l.ID
+			merged = append(merged, t)
+
+		case inBase && inLocal:
+			// Gone remotely: its tombstone was purged, or the server does
+			// not keep tombstones
+			keep, err := m.mergeMissing(l, b, Remote)
+			if err != nil {
+				return nil, err
+			}
+			if keep {
+				merged = append(merged, l)
+			}
+
+		case inBase && inRemote:
+			keep, err := m.mergeMissing(r, b, Local)
+			if err != nil {
+				return nil, err
+			}
+			if keep {
+				arrived = append(arrived, r)
+			}
+
+		case inLocal:
+			merged = append(merged, l)
+
+		case inRemote:
+			arrived = append(arrived, r)
+		}
+	}
+
+	nextID := max(local.NextID, 1)
+	taken := make(map[int]bool, len(merged))
+	for _, t := range merged {
+		taken[t.ID] = true
+		nextID = max(nextID, t.ID+1)
+	}
+	sort.Slice(arrived, func(i, j int) bool { return arrived[i].ID < arrived[j].ID })
+	var clashed []todo.Todo
+	for _, t := range arrived {
+		if taken[t.ID] || t.ID < 1 {
+			clashed = append(clashed, t)
+			continue
+		}
+		taken[t.ID] = true
+		nextID = max(nextID, t.ID+1)
+		merged = append(merged, t)
+	}
+	for _, t := range clashed {
+		if !t.Deleted() {
+			m.result.Renumbered[t.ID] = nextID
+		}
+		t.ID = nextID
+		nextID++
+		merged = append(merged, t)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].ID < merged[j].ID })
+
+	m.result.List = &todo.TodoList{Version: todo.CurrentVersion, Todos: merged, NextID: nextID}
+	if m.unresolved {
+		return m.result, fmt.Errorf("%w: %d unresolved", ErrConflict, len(m.result.Conflicts))
+	}
+	return m.result, nil
+}
+
+type merger struct {
+	resolve    Resolver
+	result     *Result
+	unresolved bool
+}
+
+// pick records a conflict and asks the resolver which side wins
+func (m *merger) pick(c Conflict) (Side, error) {
+	m.result.Conflicts = append(m.result.Conflicts, c)
+	if m.resolve == nil {
+		m.unresolved = true
+		return Local, nil
+	}
+	return m.resolve(c)
+}
+
+// mergeTodo merges a todo both sides have, field by field. Without a base
+// the tombstone of a side that deleted it stands in for one, since it
+// holds the todo as it was when deleted.
+func (m *merger) mergeTodo(base todo.Todo, inBase bool, local, remote todo.Todo) (todo.Todo, error) {
+	switch {
+	case local.Deleted() && remote.Deleted():
+		return local, nil
+	case local.Deleted():
+		if !inBase {
+			base = local
+		}
+		return m.mergeDeletion(local, remote, base, Local)
+	case remote.Deleted():
+		if !inBase {
+			base = remote
+		}
+		return m.mergeDeletion(remote, local, base, Remote)
+	}
+
+	merged := local
+	for _, f := range fields {
+		b, l, r := f.get(base), f.get(local), f.get(remote)
+		switch {
+		case l == r, r == b:
+			// Unchanged remotely or changed the same way: keep local
+		case l == b:
+			f.set(&merged, remote)
+		default:
+			side, err := m.pick(Conflict{ID: local.ID, UUID: local.UUID, Title: local.Title, Field: f.name, Base: b, Local: l, Remote: r})
+			if err != nil {
+				return todo.Todo{}, err
+			}
+			if side == Remote {
+				f.set(&merged, remote)
+			}
+		}
+	}
+	return merged, nil
+}
+
+// mergeDeletion settles a todo deleted on the deletedBy side. The deletion
+// wins unless the other side edited the todo since the base, which is a
+// conflict.
+func (m *merger) mergeDeletion(tombstone, kept, base todo.Todo, deletedBy Side) (todo.Todo, error) {
+	if !changed(base, kept) {
+		return tombstone, nil
+	}
+	side, err := m.pick(deletionConflict(kept, deletedBy))
+	if err != nil {
+		return todo.Todo{}, err
+	}
+	if side == deletedBy {
+		return tombstone, nil
+	}
+	return kept, nil
+}
+
+// mergeMissing decides whether a todo that was synced before and is now
+// missing on the deletedBy side survives because the other side edited it
+func (m *merger) mergeMissing(kept, base todo.Todo, deletedBy Side) (bool, error) {
+	if kept.Deleted() || !changed(base, kept) {
+		return false, nil
+	}
+	side, err := m.pick(deletionConflict(kept, deletedBy))
+	if err != nil {
+		return false, err
+	}
+	return side != deletedBy, nil
+}
+
+func deletionConflict(kept todo.Todo, deletedBy Side) Conflict {
+	c := Conflict{ID: kept.ID, UUID: kept.UUID, Title: kept.Title, Field: FieldDeleted, Local: "edited", Remote: "deleted"}
+	if deletedBy == Local {
+		c.Local, c.Remote = "deleted", "edited"
+	}
+	return c
+}
+
+// byUUID indexes todos by UUID, giving any that lack one their LegacyUUID
+func byUUID(todos []todo.Todo) map[string]todo.Todo {
+	m := make(map[string]todo.Todo, len(todos))
+	for _, t := range todos {
+		t.EnsureUUID()
+		m[t.UUID] = t
+	}
+	return m
+}
+
+func allUUIDs(sets ...map[string]todo.Todo) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, set := range sets {
+		for key := range set {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Base is what is recorded after a sync: the list both sides agreed on,
+// and what the server said about its copy
+type Base struct {
+	List *todo.TodoList
+	// Cursor is the server's change feed cursor, if it has one
+	Cursor string
+	// ETag is the server's ETag for its list, if it sent one
+	ETag string
+	// SyncedAt is when the sync finished
+	SyncedAt time.Time
+}
+
+// baseFile is how a Base is kept on disk: a todo list file with the
+// server's cursor and ETag beside the todos
+type baseFile struct {
+	Cursor   string    `json:"cursor,omitempty"`
+	ETag     string    `json:"etag,omitempty"`
+	SyncedAt time.Time `json:"synced_at,omitzero"`
+	*todo.TodoList
+}
+
+// LoadBase reads the Base saved after the last sync, returning nil if there
+// has not been one
+func LoadBase(path string) (*Base, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list, err := todo.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file baseFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return &Base{List: list, Cursor: file.Cursor, ETag: file.ETag, SyncedAt: file.SyncedAt}, nil
+}
+
+// SaveBase records base for the next sync
+func SaveBase(path string, base *Base) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	base.List.Version = todo.CurrentVersion
+	data, err := json.MarshalIndent(baseFile{Cursor: base.Cursor, ETag: base.ETag, SyncedAt: base.SyncedAt, TodoList: base.List}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return todo.WriteFileAtomic(path, data, 0600)
+}
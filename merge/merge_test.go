@@ -0,0 +1,320 @@
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-bubbletea/todo"
+)
+
+func item(id int, uuid, title string) todo.Todo {
+	return todo.Todo{ID: id, UUID: uuid, Title: title, Priority: "low"}
+}
+
+func list(todos ...todo.Todo) *todo.TodoList {
+	l := &todo.TodoList{Version: todo.CurrentVersion, Todos: append([]todo.Todo{}, todos...), NextID: 1}
+	for _, t := range todos {
+		l.NextID = max(l.NextID, t.ID+1)
+	}
+	return l
+}
+
+var deletedAt = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func with(t todo.Todo, change func(*todo.Todo)) todo.Todo {
+	change(&t)
+	return t
+}
+
+func retitled(t todo.Todo, title string) todo.Todo {
+	return with(t, func(t *todo.Todo) { t.Title = title })
+}
+
+func deleted(t todo.Todo) todo.Todo {
+	return with(t, func(t *todo.Todo) { t.DeletedAt = &deletedAt })
+}
+
+// describe renders todos as "id title priority", with a trailing "x" for
+// tombstones, for comparing in tests
+func describe(todos []todo.Todo) string {
+	var parts []string
+	for _, t := range todos {
+		s := fmt.Sprintf("%d %s %s", t.ID, t.Title, t.Priority)
+		if t.Deleted() {
+			s += " x"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// mergeCase is one Merge of base, local and remote
+type mergeCase struct {
+	name                string
+	base, local, remote *todo.TodoList
+	resolve             Resolver
+	want                string
+	conflicts           []string
+	renumbered          map[int]int
+	unresolved          bool
+}
+
+func TestMerge(t *testing.T) {
+	milk := item(1, "a", "Milk")
+
+	testMerge(t, []mergeCase{
+		{
+			name:   "different fields",
+			base:   list(milk),
+			local:  list(retitled(milk, "Oat milk")),
+			remote: list(with(milk, func(t *todo.Todo) { t.Priority = "high" })),
+			want:   "1 Oat milk high",
+		},
+		{
+			name:   "same field, same value",
+			base:   list(milk),
+			local:  list(retitled(milk, "Oat milk")),
+			remote: list(retitled(milk, "Oat milk")),
+			want:   "1 Oat milk low",
+		},
+		{
+			name:       "same field, no resolver",
+			base:       list(milk),
+			local:      list(retitled(milk, "Oat milk")),
+			remote:     list(retitled(milk, "Soy milk")),
+			want:       "1 Oat milk low",
+			conflicts:  []string{"title"},
+			unresolved: true,
+		},
+		{
+			name:      "same field, remote wins",
+			base:      list(milk),
+			local:     list(retitled(milk, "Oat milk")),
+			remote:    list(retitled(milk, "Soy milk")),
+			resolve:   Prefer(Remote),
+			want:      "1 Soy milk low",
+			conflicts: []string{"title"},
+		},
+		{
+			name:   "added on both sides",
+			base:   list(milk),
+			local:  list(milk, item(2, "b", "Bread")),
+			remote: list(milk, item(3, "c", "Eggs")),
+			want:   "1 Milk low, 2 Bread low, 3 Eggs low",
+		},
+	})
+}
+
+func TestMergeDeletions(t *testing.T) {
+	milk := item(1, "a", "Milk")
+
+	testMerge(t, []mergeCase{
+		{
+			name:   "deleted locally, untouched remotely",
+			base:   list(milk),
+			local:  list(deleted(milk)),
+			remote: list(milk),
+			want:   "1 Milk low x",
+		},
+		{
+			name:   "deleted remotely, untouched locally",
+			base:   list(milk),
+			local:  list(milk),
+			remote: list(deleted(milk)),
+			want:   "1 Milk low x",
+		},
+		{
+			name:       "deleted locally, edited remotely",
+			base:       list(milk),
+			local:      list(deleted(milk)),
+			remote:     list(retitled(milk, "Oat milk")),
+			want:       "1 Milk low x",
+			conflicts:  []string{FieldDeleted},
+			unresolved: true,
+		},
+		{
+			name:      "deleted locally, edit wins",
+			base:      list(milk),
+			local:     list(deleted(milk)),
+			remote:    list(retitled(milk, "Oat milk")),
+			resolve:   Prefer(Remote),
+			want:      "1 Oat milk low",
+			conflicts: []string{FieldDeleted},
+		},
+		{
+			name:      "deleted remotely, deletion wins",
+			base:      list(milk),
+			local:     list(retitled(milk, "Oat milk")),
+			remote:    list(deleted(milk)),
+			resolve:   Prefer(Remote),
+			want:      "1 Milk low x",
+			conflicts: []string{FieldDeleted},
+		},
+		{
+			name:   "purged remotely, untouched locally",
+			base:   list(milk),
+			local:  list(milk),
+			remote: list(),
+			want:   "",
+		},
+		{
+			name:      "purged remotely, edited locally",
+			base:      list(milk),
+			local:     list(retitled(milk, "Oat milk")),
+			remote:    list(),
+			resolve:   Prefer(Local),
+			want:      "1 Oat milk low",
+			conflicts: []string{FieldDeleted},
+		},
+	})
+}
+
+func TestMergeByUUID(t *testing.T) {
+	milk := item(1, "a", "Milk")
+
+	testMerge(t, []mergeCase{
+		{
+			name:       "ID collision",
+			base:       list(milk),
+			local:      list(milk, item(2, "b", "Bread")),
+			remote:     list(milk, item(2, "c", "Eggs")),
+			want:       "1 Milk low, 2 Bread low, 3 Eggs low",
+			renumbered: map[int]int{2: 3},
+		},
+		{
+			name:   "colliding tombstone is not reported",
+			base:   list(milk),
+			local:  list(milk, item(2, "b", "Bread")),
+			remote: list(milk, deleted(item(2, "c", "Eggs"))),
+			want:   "1 Milk low, 2 Bread low, 3 Eggs low x",
+		},
+		{
+			name:       "no base",
+			local:      list(milk),
+			remote:     list(item(1, "b", "Bread")),
+			want:       "1 Milk low, 2 Bread low",
+			renumbered: map[int]int{1: 2},
+		},
+	})
+}
+
+func testMerge(t *testing.T, tests []mergeCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Merge(tt.base, tt.local, tt.remote, tt.resolve)
+			if tt.unresolved {
+				if !errors.Is(err, ErrConflict) {
+					t.Fatalf("err = %v, want ErrConflict", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := describe(result.List.Todos); got != tt.want {
+				t.Errorf("merged %q, want %q", got, tt.want)
+			}
+			var fields []string
+			for _, c := range result.Conflicts {
+				fields = append(fields, c.Field)
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.conflicts) {
+				t.Errorf("conflicts on %v, want %v", fields, tt.conflicts)
+			}
+			if len(result.Renumbered) != len(tt.renumbered) {
+				t.Errorf("renumbered %v, want %v", result.Renumbered, tt.renumbered)
+			}
+			for from, to := range tt.renumbered {
+				if result.Renumbered[from] != to {
+					t.Errorf("renumbered %v, want %v", result.Renumbered, tt.renumbered)
+				}
+			}
+			for _, todo := range result.List.Todos {
+				if todo.ID >= result.List.NextID {
+					t.Errorf("NextID %d is not past #%d", result.List.NextID, todo.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeResolverError(t *testing.T) {
+	milk := item(1, "a", "Milk")
+	stop := errors.New("stop")
+	_, err := Merge(list(milk), list(retitled(milk, "Oat milk")), list(retitled(milk, "Soy milk")),
+		func(Conflict) (Side, error) { return Local, stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("err = %v, want the resolver's error", err)
+	}
+}
+
+func TestChangesAndApply(t *testing.T) {
+	milk, bread := item(1, "a", "Milk"), item(2, "b", "Bread")
+	from := list(milk, bread)
+	to := list(retitled(milk, "Oat milk"), deleted(bread), item(3, "c", "Eggs"))
+
+	changes := Changes(from, to)
+	if got, want := describe(changes), "1 Oat milk low, 2 Bread low x, 3 Eggs low"; got != want {
+		t.Errorf("Changes = %q, want %q", got, want)
+	}
+	if got := Changes(to, to); len(got) != 0 {
+		t.Errorf("Changes of a list from itself = %q", describe(got))
+	}
+
+	applied := Apply(from, changes)
+	if got, want := describe(applied.Todos), describe(to.Todos); got != want {
+		t.Errorf("Apply = %q, want %q", got, want)
+	}
+	if applied.NextID != 4 {
+		t.Errorf("Apply NextID = %d, want 4", applied.NextID)
+	}
+	if from.Todos[0].Title != "Milk" {
+		t.Error("Apply changed its base")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	milk, bread := item(1, "a", "Milk"), item(2, "b", "Bread")
+	base := list(milk, bread)
+	local := list(
+		with(milk, func(t *todo.Todo) { t.Title, t.Completed = "Oat milk", true }),
+		deleted(bread),
+		item(3, "c", "Eggs"),
+		deleted(item(4, "d", "Added and deleted")),
+	)
+
+	var got []string
+	for _, p := range Diff(base, local) {
+		got = append(got, fmt.Sprintf("%s %s %v", p.Change, p.Todo.Title, p.Fields))
+	}
+	want := []string{"edited Oat milk [title status]", "deleted Bread []", "added Eggs []"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Diff = %q, want %q", got, want)
+	}
+	if n := len(Diff(nil, list(milk))); n != 1 {
+		t.Errorf("Diff from no base found %d changes, want 1", n)
+	}
+}
+
+func TestBaseRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync", "base.json")
+	if base, err := LoadBase(path); err != nil || base != nil {
+		t.Fatalf("LoadBase of a missing file = %v, %v", base, err)
+	}
+
+	want := &Base{List: list(item(1, "a", "Milk")), ETag: `"v1"`, Cursor: "c1"}
+	if err := SaveBase(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadBase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ETag != want.ETag || got.Cursor != want.Cursor || describe(got.List.Todos) != describe(want.List.Todos) {
+		t.Errorf("LoadBase = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,370 @@
+// Package outbox keeps a durable record of the changes made to a list, in
+// the order they were made, so that sync can replay them to each remote
+// and 'todo sync --status' can show what a remote has not received yet.
+//
+// Every change committed through a store from Wrap is appended to the
+// outbox file. Each remote acknowledges the entries a successful sync
+// delivered; entries every remote that has synced has acknowledged are
+// dropped, and so is anything beyond the newest MaxEntries. A remote that
+// has never synced is not waited for, as its first sync sends the whole
+// list, and nor is one the outbox was not opened for, such as a remote
+// since removed from the config. A change that cannot be recorded is still made: sync finds it by
+// comparing the list with the server's copy, only not in the order it was
+// made.
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"todo-bubbletea/merge"
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+// MaxEntries is how many entries an outbox keeps at most. A remote that
+// has not synced for longer than that falls back on comparing the list
+// with its last synced copy for the older changes.
+const MaxEntries = 1000
+
+// Entry is one change to one todo
+type Entry struct {
+	// Seq numbers the entries of an outbox in the order they were made
+	Seq  int       `json:"seq"`
+	Time time.Time `json:"time"`
+	// Change is merge.Added, merge.Edited or merge.Deleted
+	Change string `json:"change"`
+	// Fields names the fields an edit changed
+	Fields []string `json:"fields,omitempty"`
+	// Note says more about the change, such as the list a todo moved to
+	Note string `json:"note,omitempty"`
+	// Source is the remote whose sync made the change, empty for changes
+	// made here. A remote is not sent its own changes back.
+	Source string `json:"source,omitempty"`
+	// Todo is the todo as the change left it
+	Todo todo.Todo `json:"todo"`
+}
+
+// Pending returns the entry as a change waiting to be sent
+func (e Entry) Pending() merge.Pending {
+	return merge.Pending{Todo: e.Todo, Change: e.Change, Fields: e.Fields, Note: e.Note}
+}
+
+// file is what an outbox file holds
+type file struct {
+	// Next is the Seq the next entry gets
+	Next    int     `json:"next"`
+	Entries []Entry `json:"entries"`
+	// Acked maps each remote to the last Seq a sync delivered to it
+	Acked map[string]int `json:"acked,omitempty"`
+}
+
+// Outbox is the outbox file of one list. A nil Outbox, for a store that
+// does not record its changes, is always empty.
+type Outbox struct {
+	path    string
+	remotes map[string]bool // nil for any remote
+}
+
+// Open returns the outbox kept at path, holding entries for the given
+// remotes, or for any remote if none are given. The file is created with
+// the first change.
+func Open(path string, remotes ...string) *Outbox {
+	o := &Outbox{path: path}
+	if len(remotes) > 0 {
+		o.remotes = make(map[string]bool, len(remotes))
+		for _, remote := range remotes {
+			o.remotes[remote] = true
+		}
+	}
+	return o
+}
+
+// keeps reports whether the outbox holds entries for remote
+func (o *Outbox) keeps(remote string) bool {
+	return o.remotes == nil || o.remotes[remote]
+}
+
+// Path returns where the outbox is kept
+func (o *Outbox) Path() string {
+	return o.path
+}
+
+// Pending returns the entries remote has not acknowledged, oldest first,
+// leaving out the changes its own syncs made. Mark is the Seq of the
+// newest entry, to acknowledge once they have been delivered. A remote
+// the outbox does not hold entries for has none pending.
+func (o *Outbox) Pending(remote string) (pending []Entry, mark int, err error) {
+	if o == nil {
+		return nil, 0, nil
+	}
+	f, err := o.read()
+	if err != nil {
+		return nil, 0, err
+	}
+	if !o.keeps(remote) {
+		return nil, f.Next - 1, nil
+	}
+	acked := f.Acked[remote]
+	for _, e := range f.Entries {
+		if e.Seq > acked && e.Source != remote {
+			pending = append(pending, e)
+		}
+	}
+	return pending, f.Next - 1, nil
+}
+
+// Ack records that a sync delivered every entry up to mark to remote, and
+// drops the entries no remote still waits for
+func (o *Outbox) Ack(remote string, mark int) error {
+	if o == nil || !o.keeps(remote) {
+		return nil
+	}
+	return o.update(func(f *file) {
+		if f.Acked == nil {
+			f.Acked = map[string]int{}
+		}
+		f.Acked[remote] = max(f.Acked[remote], mark)
+	})
+}
+
+// record appends entries made by source, numbering them
+func (o *Outbox) record(source string, entries []Entry) error {
+	return o.update(func(f *file) {
+		for _, e := range entries {
+			e.Seq = f.Next
+			e.Source = source
+			f.Next++
+			f.Entries = append(f.Entries, e)
+		}
+	})
+}
+
+// update applies fn to the outbox file under its lock, then compacts it
+func (o *Outbox) update(fn func(*file)) error {
+	if err := os.MkdirAll(filepath.Dir(o.path), 0700); err != nil {
+		return err
+	}
+	lock, err := todo.Lock(o.path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	f, err := o.read()
+	if err != nil {
+		return err
+	}
+	fn(f)
+	f.compact(o.keeps)
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return todo.WriteFileAtomic(o.path, data, 0600)
+}
+
+func (o *Outbox) read() (*file, error) {
+	f := &file{Next: 1}
+	data, err := os.ReadFile(o.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("parsing outbox %s: %w", o.path, err)
+	}
+	return f, nil
+}
+
+// compact forgets the remotes keeps rejects, then drops the entries every
+// remote left has acknowledged and the oldest ones beyond MaxEntries
+func (f *file) compact(keeps func(remote string) bool) {
+	for remote := range f.Acked {
+		if !keeps(remote) {
+			delete(f.Acked, remote)
+		}
+	}
+	if len(f.Acked) > 0 {
+		low := f.Next
+		for _, seq := range f.Acked {
+			low = min(low, seq)
+		}
+		i := 0
+		for i < len(f.Entries) && f.Entries[i].Seq <= low {
+			i++
+		}
+		f.Entries = f.Entries[i:]
+	}
+	if n := len(f.Entries); n > MaxEntries {
+		f.Entries = f.Entries[n-MaxEntries:]
+	}
+}
+
+// recorder is a store that records in an outbox every change committed
+// through it
+type recorder struct {
+	store.Store
+	box    *Outbox
+	source string
+	note   string
+}
+
+// Wrap returns st recording its changes in box
+func Wrap(st store.Store, box *Outbox) store.Store {
+	return &recorder{Store: st, box: box}
+}
+
+// Of returns the outbox st records its changes in, or nil if it does not
+func Of(st store.Store) *Outbox {
+	if r, ok := st.(*recorder); ok {
+		return r.box
+	}
+	return nil
+}
+
+// WithSource returns st recording its changes as made by a sync with
+// remote. Stores without an outbox are returned as they are.
+func WithSource(st store.Store, remote string) store.Store {
+	if r, ok := st.(*recorder); ok {
+		view := *r
+		view.source = remote
+		return &view
+	}
+	return st
+}
+
+// WithNote returns st recording its changes with note. Stores without an
+// outbox are returned as they are.
+func WithNote(st store.Store, note string) store.Store {
+	if r, ok := st.(*recorder); ok {
+		view := *r
+		view.note = note
+		return &view
+	}
+	return st
+}
+
+func (r *recorder) Put(t todo.Todo) error {
+	return r.Update(func(tx store.Tx) error { return tx.Put(t) })
+}
+
+func (r *recorder) Delete(id int) error {
+	return r.Update(func(tx store.Tx) error { return tx.Delete(id) })
+}
+
+// Update records what fn changed once the transaction has committed. By
+// then the change is made, so failing to record it is only logged.
+func (r *recorder) Update(fn func(store.Tx) error) error {
+	var tx *recordingTx
+	err := r.Store.Update(func(inner store.Tx) error {
+		// The transaction may be retried, so only the last run counts
+		tx = &recordingTx{Tx: inner, note: r.note}
+		return fn(tx)
+	})
+	if err != nil || len(tx.entries) == 0 {
+		return err
+	}
+	if err := r.box.record(r.source, tx.entries); err != nil {
+		log.Printf("recording the change in the outbox: %v; the next sync finds it by comparing the lists", err)
+	}
+	return nil
+}
+
+// recordingTx notes the changes made through it
+type recordingTx struct {
+	store.Tx
+	note    string
+	before  map[int]todo.Todo // every todo by ID, read on the first change
+	entries []Entry
+}
+
+func (tx *recordingTx) Put(t todo.Todo) error {
+	if err := tx.load(); err != nil {
+		return err
+	}
+	if err := tx.Tx.Put(t); err != nil {
+		return err
+	}
+	prev, existed := tx.before[t.ID]
+	t, err := tx.stored(t.ID)
+	if err != nil {
+		return err
+	}
+	tx.before[t.ID] = t
+	switch {
+	case !existed && !t.Deleted():
+		tx.add(t, merge.Added, nil)
+	case !existed:
+	case t.Deleted() && !prev.Deleted():
+		tx.add(t, merge.Deleted, nil)
+	case !t.Deleted():
+		if fields := merge.ChangedFields(prev, t); len(fields) > 0 || prev.Deleted() {
+			tx.add(t, merge.Edited, fields)
+		}
+	}
+	return nil
+}
+
+func (tx *recordingTx) Delete(id int) error {
+	if err := tx.load(); err != nil {
+		return err
+	}
+	if err := tx.Tx.Delete(id); err != nil {
+		return err
+	}
+	t, err := tx.stored(id)
+	if err != nil {
+		return err
+	}
+	tx.before[id] = t
+	tx.add(t, merge.Deleted, nil)
+	return nil
+}
+
+// stored reads back the todo with the given ID as the store wrote it,
+// with the UUID, UpdatedAt and DeletedAt it was given, so that a replay
+// sends what is really here
+func (tx *recordingTx) stored(id int) (todo.Todo, error) {
+	if t, err := tx.Tx.Get(id); !errors.Is(err, store.ErrNotFound) {
+		return t, err
+	}
+	// Tombstones are only found by listing them
+	all, err := tx.Tx.ListAll()
+	if err != nil {
+		return todo.Todo{}, err
+	}
+	for _, t := range all {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return todo.Todo{}, fmt.Errorf("todo #%d: %w", id, store.ErrNotFound)
+}
+
+func (tx *recordingTx) load() error {
+	if tx.before != nil {
+		return nil
+	}
+	todos, err := tx.Tx.ListAll()
+	if err != nil {
+		return err
+	}
+	tx.before = make(map[int]todo.Todo, len(todos))
+	for _, t := range todos {
+		tx.before[t.ID] = t
+	}
+	return nil
+}
+
+func (tx *recordingTx) add(t todo.Todo, change string, fields []string) {
+	tx.entries = append(tx.entries, Entry{Time: time.Now(), Change: change, Fields: fields, Note: tx.note, Todo: t})
+}
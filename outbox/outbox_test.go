@@ -0,0 +1,283 @@
+package outbox
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"todo-bubbletea/merge"
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+// open returns a JSON store in a temporary directory recording into its
+// own outbox
+func open(t *testing.T) (store.Store, *Outbox) {
+	t.Helper()
+	dir := t.TempDir()
+	st, err := store.Open(store.BackendJSON, filepath.Join(dir, "todos.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	box := Open(filepath.Join(dir, "outbox.json"))
+	return Wrap(st, box), box
+}
+
+func put(t *testing.T, st store.Store, item todo.Todo) {
+	t.Helper()
+	if err := st.Put(item); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func changes(entries []Entry) []string {
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Change+" "+e.Todo.Title)
+	}
+	return got
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRecordsInOrder(t *testing.T) {
+	st, box := open(t)
+	milk := todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Milk", Priority: "low"}
+	put(t, st, milk)
+	put(t, st, todo.Todo{ID: 2, UUID: todo.NewUUID(), Title: "Bread", Priority: "low"})
+	milk.Title = "Oat milk"
+	put(t, st, milk)
+	put(t, st, milk) // unchanged, so not recorded
+	if err := st.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, mark, err := box.Pending("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"added Milk", "added Bread", "edited Oat milk", "deleted Oat milk"}
+	if got := changes(pending); !equal(got, want) {
+		t.Errorf("pending = %v, want %v", got, want)
+	}
+	if mark != 4 || pending[3].Seq != 4 {
+		t.Errorf("mark = %d, last seq = %d, want 4", mark, pending[3].Seq)
+	}
+	if f := pending[2].Fields; len(f) != 1 || f[0] != "title" {
+		t.Errorf("edit fields = %v", f)
+	}
+	if !pending[3].Todo.Deleted() {
+		t.Error("the deletion does not hold a tombstone")
+	}
+}
+
+func TestRecordsStoredTodo(t *testing.T) {
+	st, box := open(t)
+	put(t, st, todo.Todo{ID: 1, Title: "Milk", Priority: "low"})
+	stored, err := st.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, _, err := box.Pending("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := pending[0].Todo
+	if got.UUID == "" || got.UUID != stored.UUID {
+		t.Errorf("recorded UUID %q, stored %q", got.UUID, stored.UUID)
+	}
+	if got.UpdatedAt == nil || !got.UpdatedAt.Equal(*stored.UpdatedAt) {
+		t.Errorf("recorded UpdatedAt %v, stored %v", got.UpdatedAt, stored.UpdatedAt)
+	}
+
+	if err := st.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	all, err := store.SnapshotAll(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tombstone := all.Todos[0]
+	pending, _, _ = box.Pending("server")
+	got = pending[len(pending)-1].Todo
+	if !got.Deleted() || !got.DeletedAt.Equal(*tombstone.DeletedAt) || !got.UpdatedAt.Equal(*tombstone.UpdatedAt) {
+		t.Errorf("recorded tombstone deleted %v, updated %v; stored %v, %v", got.DeletedAt, got.UpdatedAt, tombstone.DeletedAt, tombstone.UpdatedAt)
+	}
+}
+
+func TestReplaceRecordsEachChange(t *testing.T) {
+	st, box := open(t)
+	put(t, st, todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Milk", Priority: "low"})
+	list, err := store.SnapshotAll(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list.Todos[0].Completed = true
+	list.Todos = append(list.Todos, todo.Todo{ID: 2, UUID: todo.NewUUID(), Title: "Eggs", Priority: "low"})
+	if err := store.Replace(st, list); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, _, err := box.Pending("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := changes(pending), []string{"added Milk", "edited Milk", "added Eggs"}; !equal(got, want) {
+		t.Errorf("pending = %v, want %v", got, want)
+	}
+}
+
+func TestAckAndSource(t *testing.T) {
+	st, box := open(t)
+	put(t, st, todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Milk", Priority: "low"})
+	_, mark, _ := box.Pending("a")
+	if err := box.Ack("a", mark); err != nil {
+		t.Fatal(err)
+	}
+	// A change pulled in by a sync with a is news to b but not to a
+	put(t, WithSource(st, "a"), todo.Todo{ID: 2, UUID: todo.NewUUID(), Title: "From a", Priority: "low"})
+	put(t, WithNote(st, "moved from work"), todo.Todo{ID: 3, UUID: todo.NewUUID(), Title: "Moved", Priority: "low"})
+
+	pa, _, _ := box.Pending("a")
+	if got, want := changes(pa), []string{"added Moved"}; !equal(got, want) {
+		t.Errorf("pending for a = %v, want %v", got, want)
+	}
+	if pa[0].Note != "moved from work" || pa[0].Pending().Note != "moved from work" {
+		t.Errorf("note = %q", pa[0].Note)
+	}
+	// b has never synced, so nothing was kept for it once a had the first
+	// entry; its first sync sends the whole list anyway
+	pb, markB, _ := box.Pending("b")
+	if got, want := changes(pb), []string{"added From a", "added Moved"}; !equal(got, want) {
+		t.Errorf("pending for b = %v, want %v", got, want)
+	}
+
+	// Entries both remotes have acknowledged are dropped from the file
+	if err := box.Ack("b", markB); err != nil {
+		t.Fatal(err)
+	}
+	f, err := box.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := changes(f.Entries), []string{"added From a", "added Moved"}; !equal(got, want) {
+		t.Errorf("kept %v, want %v", got, want)
+	}
+	if pb, _, _ = box.Pending("b"); len(pb) != 0 {
+		t.Errorf("pending for b after its ack = %v", changes(pb))
+	}
+}
+
+func TestForgetsRemovedRemotes(t *testing.T) {
+	st, box := open(t)
+	put(t, st, todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Milk", Priority: "low"})
+	for _, remote := range []string{"a", "gone"} {
+		if err := box.Ack(remote, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// gone has since been removed, so only a is waited for
+	box = Open(box.Path(), "a")
+	if pending, _, _ := box.Pending("gone"); len(pending) != 0 {
+		t.Errorf("pending for a removed remote = %v", changes(pending))
+	}
+	_, mark, _ := box.Pending("a")
+	if err := box.Ack("a", mark); err != nil {
+		t.Fatal(err)
+	}
+	f, err := box.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Entries) != 0 || len(f.Acked) != 1 {
+		t.Errorf("kept %v for %v, want nothing for a alone", changes(f.Entries), f.Acked)
+	}
+}
+
+func TestFailedUpdateRecordsNothing(t *testing.T) {
+	st, box := open(t)
+	err := st.Update(func(tx store.Tx) error {
+		if err := tx.Put(todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Milk"}); err != nil {
+			return err
+		}
+		return store.ErrChanged
+	})
+	if err != store.ErrChanged {
+		t.Fatalf("Update = %v", err)
+	}
+	if pending, _, _ := box.Pending("server"); len(pending) != 0 {
+		t.Errorf("a rolled back change was recorded: %v", changes(pending))
+	}
+}
+
+func TestUnrecordedChangeIsStillMade(t *testing.T) {
+	dir := t.TempDir()
+	st, err := store.Open(store.BackendJSON, filepath.Join(dir, "todos.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	// The outbox's directory is a file, so it cannot be written
+	blocked := filepath.Join(dir, "todos.json")
+	st = Wrap(st, Open(filepath.Join(blocked, "outbox.json")))
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	put(t, st, todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Milk"})
+	if got, err := st.Get(1); err != nil || got.Title != "Milk" {
+		t.Errorf("Get = %+v, %v, want the todo the outbox could not record", got, err)
+	}
+}
+
+func TestCapsEntries(t *testing.T) {
+	st, box := open(t)
+	item := todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "v0", Priority: "low"}
+	put(t, st, item)
+	err := st.Update(func(tx store.Tx) error {
+		for i := range MaxEntries + 10 {
+			item.Title = "v" + string(rune('a'+i%26)) + string(rune('a'+i/26%26))
+			if err := tx.Put(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, mark, _ := box.Pending("server")
+	if len(pending) != MaxEntries || pending[len(pending)-1].Seq != mark {
+		t.Errorf("kept %d entries up to %d, want the newest %d", len(pending), mark, MaxEntries)
+	}
+	if pending[0].Change != merge.Edited {
+		t.Errorf("oldest kept entry = %v", pending[0].Change)
+	}
+}
+
+func TestNilOutbox(t *testing.T) {
+	var box *Outbox
+	if pending, mark, err := box.Pending("server"); pending != nil || mark != 0 || err != nil {
+		t.Errorf("nil Pending = %v, %d, %v", pending, mark, err)
+	}
+	if err := box.Ack("server", 3); err != nil {
+		t.Errorf("nil Ack = %v", err)
+	}
+	if Of(nil) != nil {
+		t.Error("Of(nil) != nil")
+	}
+}
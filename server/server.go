@@ -0,0 +1,680 @@
+// Package server implements the /api/todos protocol spoken by todo save,
+// load and sync, on top of the same stores the CLI and TUI use.
+//
+//	GET    /api/todos          the whole list, tombstones included
+//	POST   /api/todos          replace the whole list
+//	GET    /api/todos/changes  todos written since ?since=cursor
+//	POST   /api/todos/changes  write the todos given, if nothing else has been
+//	GET    /api/todos/{id}     one todo, by ID or UUID
+//	PUT    /api/todos/{id}     replace one todo, creating it if {id} is a new UUID
+//	PATCH  /api/todos/{id}     change the fields given in the body
+//	DELETE /api/todos/{id}     delete one todo, leaving a tombstone
+//
+// Responses carry an ETag for the list or the todo they are about, and
+// writes honour If-Match and If-None-Match, answering 412 Precondition
+// Failed when someone else got there first.
+//
+// A cursor is the UpdatedAt of the last todo written. Clients keep the one
+// returned by the change feed and send it back, so that a sync moves only
+// the todos changed since the last one.
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+// APIPath is where the todo list is served
+const APIPath = "/api/todos"
+
+// ChangesPath is where the change feed is served
+const ChangesPath = APIPath + "/changes"
+
+// maxBody limits request bodies
+const maxBody = 32 << 20
+
+var (
+	// errGone reports a todo that has been deleted
+	errGone = errors.New("todo has been deleted")
+	// errCursorExpired reports a cursor from before tombstones the server
+	// may have purged
+	errCursorExpired = errors.New("cursor has expired; fetch everything")
+	// errStale rejects a push from a client that has not seen every change
+	errStale = errors.New("todos changed since the cursor; fetch the changes first")
+	// errPrecondition reports an If-Match or If-None-Match that did not hold
+	errPrecondition = errors.New("todos changed since they were fetched")
+	// errTokenAuth refuses bearer tokens, which the users file has no room
+	// for
+	errTokenAuth = errors.New("this server takes a username and password, not a bearer token; add the remote with --user instead of --token")
+)
+
+// Changes is the body of the change feed: the todos written since a
+// cursor, tombstones included, and the cursor to ask from next time. A
+// push sends the same, with the cursor the client last fetched.
+type Changes struct {
+	Cursor  string      `json:"cursor"`
+	Changes []todo.Todo `json:"changes"`
+}
+
+// Opener opens the store holding a named list
+type Opener func(list string) (store.Store, error)
+
+// Server serves todo lists over HTTP. With no users every request works on
+// the default list; otherwise requests need basic auth and work on the
+// user's list.
+type Server struct {
+	open      Opener
+	list      string
+	users     map[string]User
+	unknown   User
+	cursorAge time.Duration
+	mux       *http.ServeMux
+
+	mu     sync.Mutex
+	stores map[string]store.Store
+}
+
+// New returns a server for the lists opened by open. Users without a list
+// of their own share list. The change feed refuses cursors older than
+// cursorAge, the age at which tombstones may be purged, so that clients
+// fetch everything instead of missing deletions; zero accepts any cursor.
+func New(open Opener, list string, users []User, cursorAge time.Duration) *Server {
+	s := &Server{
+		open:      open,
+		list:      list,
+		users:     make(map[string]User, len(users)),
+		cursorAge: cursorAge,
+		mux:       http.NewServeMux(),
+		stores:    make(map[string]store.Store),
+	}
+	for _, u := range users {
+		s.users[u.Name] = u
+	}
+	if len(users) > 0 {
+		s.unknown = standIn(users)
+	}
+
+	s.route("GET "+APIPath, s.getList)
+	s.route("POST "+APIPath, s.replaceList)
+	s.route("GET "+ChangesPath, s.getChanges)
+	s.route("POST "+ChangesPath, s.pushChanges)
+	s.route("GET "+APIPath+"/{id}", s.getTodo)
+	s.route("PUT "+APIPath+"/{id}", s.putTodo)
+	s.route("PATCH "+APIPath+"/{id}", s.patchTodo)
+	s.route("DELETE "+APIPath+"/{id}", s.deleteTodo)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+	s.mux.ServeHTTP(rec, r)
+
+	user, _, _ := r.BasicAuth()
+	if user == "" {
+		user = "-"
+	}
+	log.Printf("%s %s %s %d %s", user, r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+}
+
+// Close closes every store the server opened
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for list, st := range s.stores {
+		errs = append(errs, st.Close())
+		delete(s.stores, list)
+	}
+	return errors.Join(errs...)
+}
+
+// route registers a handler that runs after authentication, with the
+// store for the caller's list
+func (s *Server) route(pattern string, handle func(http.ResponseWriter, *http.Request, store.Store)) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		list, ok := s.authenticate(w, r)
+		if !ok {
+			return
+		}
+		st, err := s.store(list)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		handle(w, r, st)
+	})
+}
+
+// authenticate checks basic auth and returns the caller's list. Bearer
+// tokens are refused with a message saying so.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if len(s.users) == 0 {
+		return s.list, true
+	}
+
+	if scheme, _, _ := strings.Cut(r.Header.Get("Authorization"), " "); strings.EqualFold(scheme, "Bearer") {
+		w.Header().Set("WWW-Authenticate", `Basic realm="todo"`)
+		writeError(w, http.StatusUnauthorized, errTokenAuth)
+		return "", false
+	}
+
+	name, password, ok := r.BasicAuth()
+	user, known := s.users[name]
+	if !known {
+		user = s.unknown
+	}
+	// The password is checked even for unknown names, which takes as long
+	// as for known ones
+	if !ok || !user.CheckPassword(password) || !known {
+		w.Header().Set("WWW-Authenticate", `Basic realm="todo"`)
+		writeError(w, http.StatusUnauthorized, errors.New("invalid username or password"))
+		return "", false
+	}
+	if user.List != "" {
+		return user.List, true
+	}
+	return s.list, true
+}
+
+// store returns the open store for list, opening it on first use
+func (s *Server) store(list string) (store.Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st, ok := s.stores[list]; ok {
+		return st, nil
+	}
+	st, err := s.open(list)
+	if err != nil {
+		return nil, fmt.Errorf("opening list %s: %w", list, err)
+	}
+	s.stores[list] = st
+	return st, nil
+}
+
+func (s *Server) getList(w http.ResponseWriter, r *http.Request, st store.Store) {
+	todoList, err := store.SnapshotAll(st)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("ETag", listETag(todoList.Todos, todoList.NextID))
+	writeJSON(w, http.StatusOK, todoList)
+}
+
+// replaceList replaces the whole list. A list with no todos at all counts
+// as not existing for If-None-Match: *.
+func (s *Server) replaceList(w http.ResponseWriter, r *http.Request, st store.Store) {
+	todoList := todo.NewTodoList()
+	if err := readJSON(w, r, todoList); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	for i := range todoList.Todos {
+		todoList.Todos[i].EnsureUUID()
+	}
+
+	current, err := store.SnapshotAll(st)
+	if err == nil {
+		err = checkPreconditions(r, listETag(current.Todos, current.NextID), len(current.Todos) > 0)
+	}
+	if err == nil {
+		err = store.CompareAndReplace(st, current, todoList)
+	}
+	if errors.Is(err, store.ErrChanged) {
+		err = errPrecondition
+	}
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	s.getList(w, r, st)
+}
+
+func (s *Server) getChanges(w http.ResponseWriter, r *http.Request, st store.Store) {
+	since, err := parseCursor(r.URL.Query().Get("since"))
+	if err == nil && !since.IsZero() && s.cursorAge > 0 && time.Since(since) > s.cursorAge {
+		// Tombstones written after since may have been purged
+		err = errCursorExpired
+	}
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	var feed Changes
+	var etag string
+	err = st.View(func(tx store.Tx) error {
+		todos, err := tx.ListAll()
+		if err != nil {
+			return err
+		}
+		next, err := tx.NextID()
+		if err != nil {
+			return err
+		}
+		feed = Changes{Cursor: cursorOf(todos), Changes: changedSince(todos, since)}
+		etag = listETag(todos, next)
+		return nil
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	writeJSON(w, http.StatusOK, feed)
+}
+
+// pushChanges writes the todos in the body, matched by UUID, and returns
+// the new cursor. It refuses with 409 Conflict if anything was written
+// after the client's cursor, as the client has not merged that yet.
+func (s *Server) pushChanges(w http.ResponseWriter, r *http.Request, st store.Store) {
+	var push Changes
+	if err := readJSON(w, r, &push); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	since, err := parseCursor(push.Cursor)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	var cursor, etag string
+	err = st.Update(func(tx store.Tx) error {
+		todos, err := tx.ListAll()
+		if err != nil {
+			return err
+		}
+		if lastWrite(todos).After(since) {
+			return errStale
+		}
+
+		ids := make(map[string]int, len(todos))
+		taken := make(map[int]bool, len(todos))
+		for _, t := range todos {
+			ids[t.UUID] = t.ID
+			taken[t.ID] = true
+		}
+		for _, t := range push.Changes {
+			if t.UUID == "" {
+				return badRequest{errors.New("every change needs a uuid")}
+			}
+			t.UUID = strings.ToLower(t.UUID)
+			if id, ok := ids[t.UUID]; ok {
+				t.ID = id
+			} else if t.ID <= 0 || taken[t.ID] {
+				if t.ID, err = tx.NextID(); err != nil {
+					return err
+				}
+			}
+			if err := prepare(&t); err != nil {
+				return err
+			}
+			if err := tx.Put(t); err != nil {
+				return err
+			}
+			ids[t.UUID] = t.ID
+			taken[t.ID] = true
+		}
+
+		if todos, err = tx.ListAll(); err != nil {
+			return err
+		}
+		next, err := tx.NextID()
+		if err != nil {
+			return err
+		}
+		cursor, etag = cursorOf(todos), listETag(todos, next)
+		return nil
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	writeJSON(w, http.StatusOK, Changes{Cursor: cursor, Changes: []todo.Todo{}})
+}
+
+// parseCursor reads a cursor sent by a client. The empty cursor stands
+// for the start of time.
+func parseCursor(cursor string) (time.Time, error) {
+	if cursor == "" {
+		return time.Time{}, nil
+	}
+	since, err := time.Parse(time.RFC3339Nano, cursor)
+	if err != nil {
+		return time.Time{}, badRequest{fmt.Errorf("invalid cursor %q", cursor)}
+	}
+	return since, nil
+}
+
+// cursorOf returns the cursor for todos
+func cursorOf(todos []todo.Todo) string {
+	last := lastWrite(todos)
+	if last.IsZero() {
+		return ""
+	}
+	return last.UTC().Format(time.RFC3339Nano)
+}
+
+// lastWrite returns when the last of todos was written
+func lastWrite(todos []todo.Todo) time.Time {
+	var last time.Time
+	for _, t := range todos {
+		if t.UpdatedAt != nil && t.UpdatedAt.After(last) {
+			last = *t.UpdatedAt
+		}
+	}
+	return last
+}
+
+// changedSince returns the todos written after since; from the start of
+// time that is every todo
+func changedSince(todos []todo.Todo, since time.Time) []todo.Todo {
+	if since.IsZero() {
+		return todos
+	}
+	changed := []todo.Todo{}
+	for _, t := range todos {
+		if t.UpdatedAt != nil && t.UpdatedAt.After(since) {
+			changed = append(changed, t)
+		}
+	}
+	return changed
+}
+
+func (s *Server) getTodo(w http.ResponseWriter, r *http.Request, st store.Store) {
+	var found todo.Todo
+	err := st.View(func(tx store.Tx) error {
+		var err error
+		found, err = findLive(tx, r.PathValue("id"))
+		return err
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.Header().Set("ETag", todoETag(found))
+	writeJSON(w, http.StatusOK, found)
+}
+
+// putTodo replaces a todo with the body. A UUID that is not known yet
+// creates the todo; a deleted one brings it back.
+func (s *Server) putTodo(w http.ResponseWriter, r *http.Request, st store.Store) {
+	var body todo.Todo
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ref := r.PathValue("id")
+	status := http.StatusOK
+	err := st.Update(func(tx store.Tx) error {
+		existing, err := find(tx, ref)
+		found := err == nil
+		if !found && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		if err := checkPreconditions(r, todoETag(existing), found && !existing.Deleted()); err != nil {
+			return err
+		}
+
+		if found {
+			body.ID = existing.ID
+			body.UUID = existing.UUID
+			if body.CreatedAt.IsZero() {
+				body.CreatedAt = existing.CreatedAt
+			}
+		} else {
+			if _, perr := uuid.Parse(ref); perr != nil {
+				return err
+			}
+			if body.ID, err = tx.NextID(); err != nil {
+				return err
+			}
+			body.UUID = strings.ToLower(ref)
+			if body.CreatedAt.IsZero() {
+				body.CreatedAt = time.Now()
+			}
+			status = http.StatusCreated
+		}
+
+		body.DeletedAt = nil
+		if err := prepare(&body); err != nil {
+			return err
+		}
+		if err := tx.Put(body); err != nil {
+			return err
+		}
+		body, err = tx.Get(body.ID)
+		return err
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.Header().Set("ETag", todoETag(body))
+	writeJSON(w, status, body)
+}
+
+// patchTodo applies the fields present in the body. ID, UUID and creation
+// time cannot be changed.
+func (s *Server) patchTodo(w http.ResponseWriter, r *http.Request, st store.Store) {
+	var patch json.RawMessage
+	if err := readJSON(w, r, &patch); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var patched todo.Todo
+	err := st.Update(func(tx store.Tx) error {
+		existing, err := findLive(tx, r.PathValue("id"))
+		if err != nil {
+			return err
+		}
+		if err := checkPreconditions(r, todoETag(existing), true); err != nil {
+			return err
+		}
+		patched = existing
+		if err := json.Unmarshal(patch, &patched); err != nil {
+			return badRequest{err}
+		}
+		patched.ID, patched.UUID, patched.CreatedAt, patched.DeletedAt =
+			existing.ID, existing.UUID, existing.CreatedAt, nil
+		if err := prepare(&patched); err != nil {
+			return err
+		}
+		if err := tx.Put(patched); err != nil {
+			return err
+		}
+		patched, err = tx.Get(patched.ID)
+		return err
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.Header().Set("ETag", todoETag(patched))
+	writeJSON(w, http.StatusOK, patched)
+}
+
+func (s *Server) deleteTodo(w http.ResponseWriter, r *http.Request, st store.Store) {
+	err := st.Update(func(tx store.Tx) error {
+		existing, err := findLive(tx, r.PathValue("id"))
+		if err != nil {
+			return err
+		}
+		if err := checkPreconditions(r, todoETag(existing), true); err != nil {
+			return err
+		}
+		return tx.Delete(existing.ID)
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// find looks a todo up by ID or UUID, tombstones included
+func find(tx store.Tx, ref string) (todo.Todo, error) {
+	id, idErr := strconv.Atoi(ref)
+	todos, err := tx.ListAll()
+	if err != nil {
+		return todo.Todo{}, err
+	}
+	for _, t := range todos {
+		if (idErr == nil && t.ID == id) || strings.EqualFold(t.UUID, ref) {
+			return t, nil
+		}
+	}
+	return todo.Todo{}, store.ErrNotFound
+}
+
+// findLive is find for todos that have not been deleted
+func findLive(tx store.Tx, ref string) (todo.Todo, error) {
+	t, err := find(tx, ref)
+	if err == nil && t.Deleted() {
+		return todo.Todo{}, errGone
+	}
+	return t, err
+}
+
+// prepare checks a todo written over the API and fills in what the CLI
+// would
+func prepare(t *todo.Todo) error {
+	if strings.TrimSpace(t.Title) == "" {
+		return badRequest{errors.New("title is required")}
+	}
+	switch t.Priority {
+	case "":
+		t.Priority = "low"
+	case "low", "medium", "high":
+	default:
+		return badRequest{fmt.Errorf("invalid priority %q (want low, medium or high)", t.Priority)}
+	}
+
+	switch {
+	case t.Completed && t.CompletedAt == nil:
+		now := time.Now()
+		t.CompletedAt = &now
+	case !t.Completed:
+		t.CompletedAt = nil
+	}
+	return nil
+}
+
+// listETag returns the ETag of a list, which changes whenever anything in
+// it does
+func listETag(todos []todo.Todo, nextID int) string {
+	return etag(struct {
+		Todos  []todo.Todo `json:"todos"`
+		NextID int         `json:"next_id"`
+	}{todos, nextID})
+}
+
+// todoETag returns the ETag of a single todo
+func todoETag(t todo.Todo) string {
+	return etag(t)
+}
+
+func etag(v any) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// checkPreconditions applies the request's If-Match and If-None-Match
+// headers to a resource whose ETag is etag, or that does not exist when
+// exists is false
+func checkPreconditions(r *http.Request, etag string, exists bool) error {
+	if h := r.Header.Get("If-Match"); h != "" && !(exists && matchETag(h, etag)) {
+		return errPrecondition
+	}
+	if h := r.Header.Get("If-None-Match"); h != "" && exists && matchETag(h, etag) {
+		return errPrecondition
+	}
+	return nil
+}
+
+// matchETag reports whether etag is in a list of ETags from a header, or
+// the list is *
+func matchETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// badRequest marks an error caused by the request body
+type badRequest struct{ error }
+
+func statusFor(err error) int {
+	var bad badRequest
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errGone), errors.Is(err, errCursorExpired):
+		return http.StatusGone
+	case errors.Is(err, errStale):
+		return http.StatusConflict
+	case errors.Is(err, errPrecondition):
+		return http.StatusPreconditionFailed
+	case errors.As(err, &bad):
+		return http.StatusBadRequest
+	case errors.Is(err, todo.ErrLocked):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody))
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// statusRecorder remembers the status code for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,354 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"todo-bubbletea/store"
+	"todo-bubbletea/todo"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// serve starts a server whose lists are JSON stores in a temporary
+// directory
+func serve(t *testing.T, users []User, cursorAge time.Duration) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	open := func(list string) (store.Store, error) {
+		return store.Open(store.BackendJSON, filepath.Join(dir, list+".json"))
+	}
+	s := New(open, "default", users, cursorAge)
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	return ts
+}
+
+// call sends a request with body as JSON and decodes the answer, or the
+// error, into out when it is not nil
+func call(t *testing.T, ts *httptest.Server, method, path string, body any, header http.Header, out any) *http.Response {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, ts.URL+path, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp
+}
+
+func expect(t *testing.T, resp *http.Response, status int) {
+	t.Helper()
+	if resp.StatusCode != status {
+		t.Fatalf("%s %s: status %d, want %d", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, status)
+	}
+}
+
+func newTodo(id int, title string) todo.Todo {
+	return todo.Todo{ID: id, UUID: todo.NewUUID(), Title: title, Priority: "low"}
+}
+
+func TestReplaceList(t *testing.T) {
+	ts := serve(t, nil, 0)
+
+	var empty todo.TodoList
+	expect(t, call(t, ts, "GET", APIPath, nil, nil, &empty), http.StatusOK)
+	if len(empty.Todos) != 0 {
+		t.Fatalf("new server has %d todos", len(empty.Todos))
+	}
+
+	list := &todo.TodoList{Todos: []todo.Todo{newTodo(1, "Milk"), newTodo(2, "Bread")}, NextID: 3}
+	var got todo.TodoList
+	expect(t, call(t, ts, "POST", APIPath, list, nil, &got), http.StatusOK)
+	if len(got.Todos) != 2 || got.Todos[1].Title != "Bread" || got.Todos[0].UpdatedAt == nil {
+		t.Errorf("replaced list = %+v", got.Todos)
+	}
+
+	expect(t, call(t, ts, "GET", APIPath, nil, nil, &got), http.StatusOK)
+	if len(got.Todos) != 2 || got.Todos[0].Title != "Milk" {
+		t.Errorf("list read back = %+v", got.Todos)
+	}
+	expect(t, call(t, ts, "POST", APIPath, map[string]any{"todos": "none"}, nil, nil), http.StatusBadRequest)
+}
+
+func TestPreconditions(t *testing.T) {
+	ts := serve(t, nil, 0)
+
+	resp := call(t, ts, "GET", APIPath, nil, nil, nil)
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("the list has no ETag")
+	}
+
+	list := &todo.TodoList{Todos: []todo.Todo{newTodo(1, "Milk"), newTodo(2, "Bread")}, NextID: 3}
+	// An empty list counts as not existing yet
+	expect(t, call(t, ts, "POST", APIPath, list, http.Header{"If-Match": {etag}}, nil), http.StatusPreconditionFailed)
+	resp = call(t, ts, "POST", APIPath, list, http.Header{"If-None-Match": {"*"}}, nil)
+	expect(t, resp, http.StatusOK)
+	if resp.Header.Get("ETag") == etag {
+		t.Error("the ETag did not change")
+	}
+
+	// The list exists now, and changes with every write
+	expect(t, call(t, ts, "POST", APIPath, list, http.Header{"If-None-Match": {"*"}}, nil), http.StatusPreconditionFailed)
+	current := resp.Header.Get("ETag")
+	list.Todos[0].Title = "Oat milk"
+	expect(t, call(t, ts, "POST", APIPath, list, http.Header{"If-Match": {current}}, nil), http.StatusOK)
+	expect(t, call(t, ts, "POST", APIPath, list, http.Header{"If-Match": {current}}, nil), http.StatusPreconditionFailed)
+
+	// Each todo has an ETag of its own
+	path := APIPath + "/" + list.Todos[1].UUID
+	resp = call(t, ts, "GET", path, nil, nil, nil)
+	expect(t, resp, http.StatusOK)
+	etag = resp.Header.Get("ETag")
+	expect(t, call(t, ts, "PATCH", path, map[string]any{"priority": "high"}, http.Header{"If-Match": {etag}}, nil), http.StatusOK)
+	expect(t, call(t, ts, "PATCH", path, map[string]any{"title": "Rye"}, http.Header{"If-Match": {etag}}, nil), http.StatusPreconditionFailed)
+}
+
+func TestChangeFeed(t *testing.T) {
+	ts := serve(t, nil, 0)
+	milk, bread := newTodo(1, "Milk"), newTodo(2, "Bread")
+
+	var feed Changes
+	expect(t, call(t, ts, "GET", ChangesPath, nil, nil, &feed), http.StatusOK)
+	if feed.Cursor != "" || len(feed.Changes) != 0 {
+		t.Fatalf("empty server's feed = %+v", feed)
+	}
+
+	var pushed Changes
+	expect(t, call(t, ts, "POST", ChangesPath, Changes{Changes: []todo.Todo{milk, bread}}, nil, &pushed), http.StatusOK)
+	if pushed.Cursor == "" {
+		t.Fatal("a push returned no cursor")
+	}
+
+	// Someone else edits a todo after that cursor
+	edit := map[string]any{"title": "Oat milk"}
+	expect(t, call(t, ts, "PATCH", APIPath+"/"+milk.UUID, edit, nil, nil), http.StatusOK)
+
+	// A push from the old cursor is refused until the client catches up
+	expect(t, call(t, ts, "POST", ChangesPath, Changes{Cursor: pushed.Cursor, Changes: []todo.Todo{bread}}, nil, nil), http.StatusConflict)
+
+	expect(t, call(t, ts, "GET", ChangesPath+"?since="+pushed.Cursor, nil, nil, &feed), http.StatusOK)
+	if len(feed.Changes) != 1 || feed.Changes[0].Title != "Oat milk" {
+		t.Fatalf("changes since the push = %+v, want the edit", feed.Changes)
+	}
+
+	bread.Completed = true
+	expect(t, call(t, ts, "POST", ChangesPath, Changes{Cursor: feed.Cursor, Changes: []todo.Todo{bread}}, nil, &pushed), http.StatusOK)
+
+	var list todo.TodoList
+	call(t, ts, "GET", APIPath, nil, nil, &list)
+	if !list.Todos[1].Completed || list.Todos[1].CompletedAt == nil {
+		t.Errorf("pushed change was not stored: %+v", list.Todos[1])
+	}
+
+	expect(t, call(t, ts, "GET", ChangesPath+"?since=yesterday", nil, nil, nil), http.StatusBadRequest)
+	expect(t, call(t, ts, "POST", ChangesPath, Changes{Cursor: pushed.Cursor, Changes: []todo.Todo{{Title: "No UUID"}}}, nil, nil), http.StatusBadRequest)
+}
+
+func TestChangeFeedIDClash(t *testing.T) {
+	ts := serve(t, nil, 0)
+	expect(t, call(t, ts, "POST", ChangesPath, Changes{Changes: []todo.Todo{newTodo(1, "Milk")}}, nil, nil), http.StatusOK)
+
+	var feed Changes
+	call(t, ts, "GET", ChangesPath, nil, nil, &feed)
+	expect(t, call(t, ts, "POST", ChangesPath, Changes{Cursor: feed.Cursor, Changes: []todo.Todo{newTodo(1, "Bread")}}, nil, nil), http.StatusOK)
+
+	var list todo.TodoList
+	call(t, ts, "GET", APIPath, nil, nil, &list)
+	if len(list.Todos) != 2 || list.Todos[1].ID != 2 || list.Todos[1].Title != "Bread" {
+		t.Errorf("list = %+v, want Bread renumbered to #2", list.Todos)
+	}
+}
+
+func TestExpiredCursor(t *testing.T) {
+	ts := serve(t, nil, time.Hour)
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano)
+	expect(t, call(t, ts, "GET", ChangesPath+"?since="+old, nil, nil, nil), http.StatusGone)
+	recent := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339Nano)
+	expect(t, call(t, ts, "GET", ChangesPath+"?since="+recent, nil, nil, nil), http.StatusOK)
+}
+
+func TestSingleTodo(t *testing.T) {
+	ts := serve(t, nil, 0)
+	id := todo.NewUUID()
+	path := APIPath + "/" + id
+
+	var created todo.Todo
+	expect(t, call(t, ts, "PUT", path, todo.Todo{Title: "Milk"}, nil, &created), http.StatusCreated)
+	if created.ID != 1 || created.UUID != id || created.Priority != "low" || created.CreatedAt.IsZero() {
+		t.Errorf("created %+v", created)
+	}
+
+	var got todo.Todo
+	expect(t, call(t, ts, "GET", APIPath+"/1", nil, nil, &got), http.StatusOK)
+	if got.UUID != id {
+		t.Errorf("GET by ID found %+v", got)
+	}
+
+	var patched todo.Todo
+	expect(t, call(t, ts, "PATCH", path, map[string]any{"priority": "high", "id": 9}, nil, &patched), http.StatusOK)
+	if patched.Priority != "high" || patched.Title != "Milk" || patched.ID != 1 {
+		t.Errorf("patched %+v", patched)
+	}
+	expect(t, call(t, ts, "PATCH", path, map[string]any{"priority": "urgent"}, nil, nil), http.StatusBadRequest)
+	expect(t, call(t, ts, "PUT", path, todo.Todo{Title: " "}, nil, nil), http.StatusBadRequest)
+
+	expect(t, call(t, ts, "DELETE", path, nil, nil, nil), http.StatusNoContent)
+	expect(t, call(t, ts, "GET", path, nil, nil, nil), http.StatusGone)
+	expect(t, call(t, ts, "DELETE", path, nil, nil, nil), http.StatusGone)
+	expect(t, call(t, ts, "GET", APIPath+"/42", nil, nil, nil), http.StatusNotFound)
+
+	// PUT brings a deleted todo back
+	expect(t, call(t, ts, "PUT", path, todo.Todo{Title: "Milk again"}, nil, &got), http.StatusOK)
+	if got.ID != 1 || got.Deleted() {
+		t.Errorf("restored %+v", got)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	hash := func(password string) string {
+		h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(h)
+	}
+	ts := serve(t, []User{
+		{Name: "alice", PasswordHash: hash("secret"), List: "alice"},
+		{Name: "bob", PasswordHash: hash("hunter2")},
+	}, 0)
+
+	as := func(name, password string) http.Header {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.SetBasicAuth(name, password)
+		return req.Header
+	}
+
+	resp := call(t, ts, "GET", APIPath, nil, nil, nil)
+	expect(t, resp, http.StatusUnauthorized)
+	if !strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Basic") {
+		t.Errorf("WWW-Authenticate = %q", resp.Header.Get("WWW-Authenticate"))
+	}
+	expect(t, call(t, ts, "GET", APIPath, nil, as("alice", "wrong"), nil), http.StatusUnauthorized)
+	expect(t, call(t, ts, "GET", APIPath, nil, as("carol", "secret"), nil), http.StatusUnauthorized)
+	expect(t, call(t, ts, "GET", APIPath, nil, as("", ""), nil), http.StatusUnauthorized)
+
+	// Tokens are refused with a message saying what to use instead
+	var body struct{ Error string }
+	resp = call(t, ts, "GET", APIPath, nil, http.Header{"Authorization": {"Bearer abc"}}, &body)
+	expect(t, resp, http.StatusUnauthorized)
+	if !strings.Contains(body.Error, "bearer token") {
+		t.Errorf("refusing a token said %q", body.Error)
+	}
+
+	// alice has a list of her own; bob works on the shared one
+	milk := &todo.TodoList{Todos: []todo.Todo{newTodo(1, "Milk")}, NextID: 2}
+	expect(t, call(t, ts, "POST", APIPath, milk, as("alice", "secret"), nil), http.StatusOK)
+	var list todo.TodoList
+	expect(t, call(t, ts, "GET", APIPath, nil, as("bob", "hunter2"), &list), http.StatusOK)
+	if len(list.Todos) != 0 {
+		t.Errorf("bob sees alice's todos: %+v", list.Todos)
+	}
+	expect(t, call(t, ts, "GET", APIPath, nil, as("alice", "secret"), &list), http.StatusOK)
+	if len(list.Todos) != 1 {
+		t.Errorf("alice sees %+v, want her todo", list.Todos)
+	}
+}
+
+func TestStandIn(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := standIn([]User{{Name: "alice", PasswordHash: string(hash)}})
+	if cost, err := bcrypt.Cost([]byte(u.PasswordHash)); err != nil || cost != bcrypt.MinCost {
+		t.Errorf("stand-in hash cost = %d, %v, want %d like the users'", cost, err, bcrypt.MinCost)
+	}
+	for _, password := range []string{"", "secret"} {
+		if u.CheckPassword(password) {
+			t.Errorf("the stand-in accepts %q", password)
+		}
+	}
+}
+
+func TestUsersFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+	if users, err := LoadUsers(path); err != nil || users != nil {
+		t.Fatalf("LoadUsers of a missing file = %v, %v", users, err)
+	}
+
+	users := []User{
+		{Name: "bob", PasswordHash: "$2y$05$bob"},
+		{Name: "alice", PasswordHash: "$2y$05$alice", List: "alice"},
+	}
+	if err := SaveUsers(path, users); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("users file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, append([]byte("# accounts\n\n"), data...), 0600)
+
+	got, err := LoadUsers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != users[0] || got[1] != users[1] {
+		t.Errorf("LoadUsers = %+v, want %+v", got, users)
+	}
+
+	for _, bad := range []string{"alice\n", "alice:plain\n", ":$2y$05$x\n", "a:$2y$05$x:list:extra\n"} {
+		os.WriteFile(path, []byte(bad), 0600)
+		if _, err := LoadUsers(path); err == nil {
+			t.Errorf("LoadUsers accepted %q", bad)
+		}
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := User{Name: "alice", PasswordHash: hash}
+	if !u.CheckPassword("secret") || u.CheckPassword("Secret") {
+		t.Error("CheckPassword does not tell the password apart")
+	}
+}
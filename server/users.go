@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"todo-bubbletea/todo"
+)
+
+// User is an account allowed to use the server
+type User struct {
+	Name string
+	// PasswordHash is a bcrypt hash, as written by 'htpasswd -B'
+	PasswordHash string
+	// List is the named list the user works on; empty means the list the
+	// server was started with, shared by every such user
+	List string
+}
+
+// CheckPassword reports whether password is the user's
+func (u User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+// standIn returns a user no password matches, for checking passwords
+// against when the name is unknown so that the time taken does not tell
+// which names exist. Its hash has the same cost as the first user's.
+func standIn(users []User) User {
+	cost := bcrypt.DefaultCost
+	if len(users) > 0 {
+		if c, err := bcrypt.Cost([]byte(users[0].PasswordHash)); err == nil {
+			cost = c
+		}
+	}
+	hash, _ := bcrypt.GenerateFromPassword([]byte(rand.Text()), cost)
+	return User{PasswordHash: string(hash)}
+}
+
+// HashPassword returns the bcrypt hash stored for password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// LoadUsers reads a users file: one "name:bcrypt-hash" line per user, as
+// written by 'htpasswd -B', optionally followed by ":list". Blank lines and
+// lines starting with # are skipped. A missing file yields no users.
+func LoadUsers(path string) ([]User, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var users []User
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) < 2 || len(fields) > 3 || fields[0] == "" {
+			return nil, fmt.Errorf("%s:%d: want name:hash or name:hash:list", path, n)
+		}
+		if !strings.HasPrefix(fields[1], "$2") {
+			return nil, fmt.Errorf("%s:%d: password for %s is not a bcrypt hash", path, n, fields[0])
+		}
+		user := User{Name: fields[0], PasswordHash: fields[1]}
+		if len(fields) == 3 {
+			user.List = fields[2]
+		}
+		users = append(users, user)
+	}
+	return users, scanner.Err()
+}
+
+// SaveUsers writes users to path, readable only by the owner
+func SaveUsers(path string, users []User) error {
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+
+	var b strings.Builder
+	for _, u := range users {
+		b.WriteString(u.Name + ":" + u.PasswordHash)
+		if u.List != "" {
+			b.WriteString(":" + u.List)
+		}
+		b.WriteString("\n")
+	}
+	return todo.WriteFileAtomic(path, []byte(b.String()), 0600)
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	bolterrors "go.etcd.io/bbolt/errors"
+
+	"todo-bubbletea/todo"
+)
+
+var (
+	boltTodos = []byte("todos")
+	boltMeta  = []byte("meta")
+	keyNextID = []byte("next_id")
+)
+
+// boltWait is how long to wait for another process to close the database
+const boltWait = 2 * time.Second
+
+// BoltStore keeps todos in an embedded BoltDB file. Bolt allows only one
+// writer process to hold the file open, so the database is opened for each
+// transaction rather than for the life of the store; that keeps the CLI
+// usable while the TUI is running.
+type BoltStore struct {
+	oneShot
+	path string
+}
+
+// OpenBolt returns a store backed by the BoltDB file at path
+func OpenBolt(path string) *BoltStore {
+	s := &BoltStore{path: path}
+	s.oneShot = oneShot{r: s}
+	return s
+}
+
+func (s *BoltStore) open(readOnly bool) (*bolt.DB, error) {
+	// A read-only open cannot create the file, so the first access
+	// always opens for writing
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		readOnly = false
+	}
+
+	db, err := bolt.Open(s.path, 0600, &bolt.Options{Timeout: boltWait, ReadOnly: readOnly})
+	if errors.Is(err, bolterrors.ErrTimeout) {
+		return nil, fmt.Errorf("%w: %s", todo.ErrLocked, s.path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !readOnly {
+		err = db.Update(func(tx *bolt.Tx) error {
+			if _, err := tx.CreateBucketIfNotExists(boltTodos); err != nil {
+				return err
+			}
+			_, err := tx.CreateBucketIfNotExists(boltMeta)
+			return err
+		})
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+func (s *BoltStore) View(fn func(Tx) error) error {
+	db, err := s.open(true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (s *BoltStore) Update(fn func(Tx) error) error {
+	db, err := s.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return nil
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+// boltKey encodes IDs big-endian so Bolt's byte ordering is ID order
+func boltKey(id int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(id))
+	return key
+}
+
+func (t *boltTx) Get(id int) (todo.Todo, error) {
+	data := t.tx.Bucket(boltTodos).Get(boltKey(id))
+	if data == nil {
+		return todo.Todo{}, ErrNotFound
+	}
+	return getLive(decodeTodo(data))
+}
+
+func (t *boltTx) List() ([]todo.Todo, error) {
+	todos, err := t.ListAll()
+	return todo.Live(todos), err
+}
+
+func (t *boltTx) ListAll() ([]todo.Todo, error) {
+	todos := []todo.Todo{}
+	err := t.tx.Bucket(boltTodos).ForEach(func(_, data []byte) error {
+		item, err := decodeTodo(data)
+		if err != nil {
+			return err
+		}
+		todos = append(todos, item)
+		return nil
+	})
+	return todos, err
+}
+
+func (t *boltTx) Put(item todo.Todo) error {
+	item = stamp(item)
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if err := t.tx.Bucket(boltTodos).Put(boltKey(item.ID), data); err != nil {
+		return err
+	}
+
+	next, err := t.NextID()
+	if err != nil {
+		return err
+	}
+	if item.ID >= next {
+		return t.SetNextID(item.ID + 1)
+	}
+	return nil
+}
+
+func (t *boltTx) Delete(id int) error {
+	return softDelete(t, id)
+}
+
+func (t *boltTx) Purge(id int) error {
+	b := t.tx.Bucket(boltTodos)
+	if b.Get(boltKey(id)) == nil {
+		return ErrNotFound
+	}
+	return b.Delete(boltKey(id))
+}
+
+func (t *boltTx) NextID() (int, error) {
+	value := t.tx.Bucket(boltMeta).Get(keyNextID)
+	if value == nil {
+		return 1, nil
+	}
+	return strconv.Atoi(string(value))
+}
+
+func (t *boltTx) SetNextID(id int) error {
+	return t.tx.Bucket(boltMeta).Put(keyNextID, []byte(strconv.Itoa(id)))
+}
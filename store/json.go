@@ -0,0 +1,119 @@
+package store
+
+import (
+	"todo-bubbletea/todo"
+)
+
+// JSONStore keeps todos in a single JSON file in the todo package format.
+// Every transaction takes the file lock, loads the file and, for updates,
+// writes it back atomically.
+type JSONStore struct {
+	oneShot
+	path string
+}
+
+// OpenJSON returns a store backed by the JSON file at path
+func OpenJSON(path string) *JSONStore {
+	s := &JSONStore{path: path}
+	s.oneShot = oneShot{r: s}
+	return s
+}
+
+// Path returns the JSON file the store reads and writes
+func (s *JSONStore) Path() string {
+	return s.path
+}
+
+func (s *JSONStore) View(fn func(Tx) error) error {
+	lock, err := todo.Lock(s.path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	todoList, err := todo.Load(s.path)
+	if err != nil {
+		return err
+	}
+	return fn(&listTx{list: todoList})
+}
+
+func (s *JSONStore) Update(fn func(Tx) error) error {
+	return todo.Update(s.path, func(todoList *todo.TodoList) error {
+		return fn(&listTx{list: todoList})
+	})
+}
+
+func (s *JSONStore) Close() error {
+	return nil
+}
+
+// listTx runs transactions against an in-memory TodoList. The slice keeps
+// the file's order; new todos are appended.
+type listTx struct {
+	list *todo.TodoList
+}
+
+func (tx *listTx) index(id int) int {
+	for i, t := range tx.list.Todos {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (tx *listTx) Get(id int) (todo.Todo, error) {
+	i := tx.index(id)
+	if i < 0 {
+		return todo.Todo{}, ErrNotFound
+	}
+	return getLive(tx.list.Todos[i], nil)
+}
+
+func (tx *listTx) List() ([]todo.Todo, error) {
+	todos, err := tx.ListAll()
+	return todo.Live(todos), err
+}
+
+func (tx *listTx) ListAll() ([]todo.Todo, error) {
+	todos := make([]todo.Todo, len(tx.list.Todos))
+	copy(todos, tx.list.Todos)
+	sortByID(todos)
+	return todos, nil
+}
+
+func (tx *listTx) Put(t todo.Todo) error {
+	t = stamp(t)
+	if i := tx.index(t.ID); i >= 0 {
+		tx.list.Todos[i] = t
+	} else {
+		tx.list.Todos = append(tx.list.Todos, t)
+	}
+	if t.ID >= tx.list.NextID {
+		tx.list.NextID = t.ID + 1
+	}
+	return nil
+}
+
+func (tx *listTx) Delete(id int) error {
+	return softDelete(tx, id)
+}
+
+func (tx *listTx) Purge(id int) error {
+	i := tx.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	tx.list.Todos = append(tx.list.Todos[:i], tx.list.Todos[i+1:]...)
+	return nil
+}
+
+func (tx *listTx) NextID() (int, error) {
+	return tx.list.NextID, nil
+}
+
+func (tx *listTx) SetNextID(id int) error {
+	tx.list.NextID = id
+	return nil
+}
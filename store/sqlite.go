@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	_ "modernc.org/sqlite"
+
+	"todo-bubbletea/todo"
+)
+
+// sqliteSchema creates the tables on first open. Todos are stored as the
+// same JSON the file backend writes, so every backend keeps every field.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS todos (
+	id   INTEGER PRIMARY KEY,
+	data TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+INSERT OR IGNORE INTO meta (key, value) VALUES ('next_id', '1');
+`
+
+// SQLiteStore keeps todos in an embedded SQLite database
+type SQLiteStore struct {
+	oneShot
+	db *sql.DB
+}
+
+// OpenSQLite opens or creates the SQLite database at path
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	// Writers take the database lock up front and wait for other
+	// processes instead of failing straight away with SQLITE_BUSY
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() +
+		"?_txlock=immediate&_pragma=busy_timeout(2000)&_pragma=journal_mode(wal)"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialising %s: %w", path, err)
+	}
+
+	s := &SQLiteStore{db: db}
+	s.oneShot = oneShot{r: s}
+	return s, nil
+}
+
+func (s *SQLiteStore) View(fn func(Tx) error) error {
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(&sqliteTx{tx: tx})
+}
+
+func (s *SQLiteStore) Update(fn func(Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&sqliteTx{tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+type sqliteTx struct {
+	tx *sql.Tx
+}
+
+func (t *sqliteTx) Get(id int) (todo.Todo, error) {
+	var data string
+	err := t.tx.QueryRow(`SELECT data FROM todos WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return todo.Todo{}, ErrNotFound
+	}
+	if err != nil {
+		return todo.Todo{}, err
+	}
+
+	return getLive(decodeTodo([]byte(data)))
+}
+
+func (t *sqliteTx) List() ([]todo.Todo, error) {
+	todos, err := t.ListAll()
+	return todo.Live(todos), err
+}
+
+func (t *sqliteTx) ListAll() ([]todo.Todo, error) {
+	rows, err := t.tx.Query(`SELECT data FROM todos ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []todo.Todo{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		item, err := decodeTodo([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, item)
+	}
+	return todos, rows.Err()
+}
+
+func (t *sqliteTx) Put(item todo.Todo) error {
+	item = stamp(item)
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	_, err = t.tx.Exec(`INSERT INTO todos (id, data) VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data`, item.ID, string(data))
+	if err != nil {
+		return err
+	}
+
+	next, err := t.NextID()
+	if err != nil {
+		return err
+	}
+	if item.ID >= next {
+		return t.SetNextID(item.ID + 1)
+	}
+	return nil
+}
+
+func (t *sqliteTx) Delete(id int) error {
+	return softDelete(t, id)
+}
+
+func (t *sqliteTx) Purge(id int) error {
+	res, err := t.tx.Exec(`DELETE FROM todos WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (t *sqliteTx) NextID() (int, error) {
+	var value string
+	err := t.tx.QueryRow(`SELECT value FROM meta WHERE key = 'next_id'`).Scan(&value)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+func (t *sqliteTx) SetNextID(id int) error {
+	_, err := t.tx.Exec(`UPDATE meta SET value = ? WHERE key = 'next_id'`, strconv.Itoa(id))
+	return err
+}
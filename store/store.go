@@ -0,0 +1,431 @@
+// Package store persists todos behind a small key-value style interface so
+// the CLI and the TUI can run on top of the JSON file, SQLite or BoltDB.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-bubbletea/todo"
+)
+
+// Backend names accepted by Open
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+	BackendBolt   = "bolt"
+)
+
+// Backends lists every backend name accepted by Open
+var Backends = []string{BackendJSON, BackendSQLite, BackendBolt}
+
+// ErrNotFound is returned when no todo has the requested ID
+var ErrNotFound = errors.New("todo not found")
+
+// ErrAmbiguous is returned by Resolve when a UUID prefix matches more
+// than one todo
+var ErrAmbiguous = errors.New("todo reference is ambiguous")
+
+// ErrChanged is returned by CompareAndReplace when the store was written
+// to after it was read
+var ErrChanged = errors.New("todos changed since they were read")
+
+// Tx is a view of the store inside a transaction
+type Tx interface {
+	// Get returns the todo with the given ID or ErrNotFound. Deleted
+	// todos are not found.
+	Get(id int) (todo.Todo, error)
+	// List returns every todo that has not been deleted, ordered by ID
+	List() ([]todo.Todo, error)
+	// ListAll is List including tombstones
+	ListAll() ([]todo.Todo, error)
+	// Put inserts or replaces a todo by ID, setting its UpdatedAt. The ID
+	// counter is moved past t.ID so it is never handed out again.
+	Put(t todo.Todo) error
+	// Delete turns a todo into a tombstone, returning ErrNotFound if it
+	// does not exist or is already deleted
+	Delete(id int) error
+	// Purge removes a todo or tombstone for good
+	Purge(id int) error
+	// NextID returns the ID the next new todo should use
+	NextID() (int, error)
+	// SetNextID moves the ID counter, used when copying whole lists
+	SetNextID(id int) error
+}
+
+// Store is a persistent collection of todos. The single-item methods each
+// run in their own transaction; use Update to group several changes.
+type Store interface {
+	Get(id int) (todo.Todo, error)
+	List() ([]todo.Todo, error)
+	Put(t todo.Todo) error
+	Delete(id int) error
+
+	// View runs fn in a read-only transaction
+	View(fn func(Tx) error) error
+	// Update runs fn in a read-write transaction. Nothing is written if
+	// fn returns an error.
+	Update(fn func(Tx) error) error
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// DefaultPath returns the file a backend uses when no path is configured
+func DefaultPath(backend string) string {
+	switch backend {
+	case BackendSQLite:
+		return "todos.db"
+	case BackendBolt:
+		return "todos.bolt"
+	default:
+		return todo.DefaultFile
+	}
+}
+
+// BackendForPath guesses the backend from a file extension, returning the
+// empty string when the extension is not recognised
+func BackendForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return BackendJSON
+	case ".db", ".sqlite", ".sqlite3":
+		return BackendSQLite
+	case ".bolt", ".bbolt":
+		return BackendBolt
+	default:
+		return ""
+	}
+}
+
+// Open opens the named backend at path. An empty backend means JSON and an
+// empty path means the backend's default file. The directory holding the
+// file is created if needed.
+func Open(backend, path string) (Store, error) {
+	if backend == "" {
+		backend = BackendJSON
+	}
+	if path == "" {
+		path = DefaultPath(backend)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	switch backend {
+	case BackendJSON:
+		return OpenJSON(path), nil
+	case BackendSQLite:
+		return OpenSQLite(path)
+	case BackendBolt:
+		return OpenBolt(path), nil
+	default:
+		return nil, fmt.Errorf("unknown store backend %q (want one of %v)", backend, Backends)
+	}
+}
+
+// Snapshot reads the todos that have not been deleted as a TodoList
+func Snapshot(s Store) (*todo.TodoList, error) {
+	return snapshot(s, Tx.List)
+}
+
+// SnapshotAll reads the whole store, tombstones included, as a TodoList
+func SnapshotAll(s Store) (*todo.TodoList, error) {
+	return snapshot(s, Tx.ListAll)
+}
+
+func snapshot(s Store, list func(Tx) ([]todo.Todo, error)) (*todo.TodoList, error) {
+	var todoList *todo.TodoList
+	err := s.View(func(tx Tx) error {
+		var err error
+		todoList, err = snapshotTx(tx, list)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todoList, nil
+}
+
+func snapshotTx(tx Tx, list func(Tx) ([]todo.Todo, error)) (*todo.TodoList, error) {
+	todos, err := list(tx)
+	if err != nil {
+		return nil, err
+	}
+	nextID, err := tx.NextID()
+	if err != nil {
+		return nil, err
+	}
+	todoList := todo.NewTodoList()
+	todoList.Todos = todos
+	todoList.NextID = nextID
+	return todoList, nil
+}
+
+// Replace makes the store hold exactly the todos in todoList. Todos it
+// drops are kept as tombstones, and tombstones in todoList are stored as
+// such.
+func Replace(s Store, todoList *todo.TodoList) error {
+	return s.Update(func(tx Tx) error {
+		return replaceTx(tx, todoList)
+	})
+}
+
+// CompareAndReplace replaces the store's todos with todoList only if it
+// still holds exactly what SnapshotAll returned as old, and returns
+// ErrChanged otherwise
+func CompareAndReplace(s Store, old, todoList *todo.TodoList) error {
+	return s.Update(func(tx Tx) error {
+		current, err := snapshotTx(tx, Tx.ListAll)
+		if err != nil {
+			return err
+		}
+		if current.NextID != old.NextID || !reflect.DeepEqual(current.Todos, old.Todos) {
+			return ErrChanged
+		}
+		return replaceTx(tx, todoList)
+	})
+}
+
+func replaceTx(tx Tx, todoList *todo.TodoList) error {
+	keep := make(map[int]bool, len(todoList.Todos))
+	for _, t := range todoList.Todos {
+		keep[t.ID] = true
+	}
+
+	existing, err := tx.ListAll()
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]todo.Todo, len(existing))
+	for _, t := range existing {
+		byID[t.ID] = t
+		if keep[t.ID] || t.Deleted() {
+			continue
+		}
+		if err := tx.Delete(t.ID); err != nil {
+			return err
+		}
+	}
+	for _, t := range todoList.Todos {
+		// Todos that are already stored as given keep their UpdatedAt
+		if old, ok := byID[t.ID]; ok && sameTodo(old, t) {
+			continue
+		}
+		if err := tx.Put(t); err != nil {
+			return err
+		}
+	}
+	return tx.SetNextID(todoList.NextID)
+}
+
+// sameTodo reports whether a and b differ only in when they were written
+func sameTodo(a, b todo.Todo) bool {
+	a.UpdatedAt, b.UpdatedAt = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// Copy moves every todo from src into dst, which must be empty, and
+// returns how many were copied. Tombstones are copied too but not counted.
+func Copy(dst, src Store) (int, error) {
+	todoList, err := SnapshotAll(src)
+	if err != nil {
+		return 0, fmt.Errorf("reading source store: %w", err)
+	}
+
+	err = dst.Update(func(tx Tx) error {
+		existing, err := tx.List()
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("destination already holds %d todos", len(existing))
+		}
+		return replaceTx(tx, todoList)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(todo.Live(todoList.Todos)), nil
+}
+
+// Move transfers one todo from src to dst and returns it as stored in dst.
+// The todo takes the next ID in dst and a new UUID, since sync sees the
+// move as a deletion from src and an addition to dst. It is written to
+// dst before being removed from src, so a failure part way leaves a copy
+// rather than losing it.
+func Move(dst, src Store, id int) (todo.Todo, error) {
+	item, err := src.Get(id)
+	if err != nil {
+		return todo.Todo{}, err
+	}
+
+	err = dst.Update(func(tx Tx) error {
+		nextID, err := tx.NextID()
+		if err != nil {
+			return err
+		}
+		item.ID = nextID
+		item.UUID = todo.NewUUID()
+		return tx.Put(item)
+	})
+	if err != nil {
+		return todo.Todo{}, fmt.Errorf("writing destination list: %w", err)
+	}
+
+	if err := src.Delete(id); err != nil {
+		return item, fmt.Errorf("removing from source list: %w", err)
+	}
+	return item, nil
+}
+
+// Resolve finds the todo that ref names: its ID, its UUID, or a prefix of
+// the UUID that only one todo has. A number is only ever taken as an ID,
+// so a missing or deleted ID is not found rather than matching whichever
+// UUID happens to start with its digits. Deleted todos are not found.
+func Resolve(s Store, ref string) (todo.Todo, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return s.Get(id)
+	}
+	if ref == "" {
+		return todo.Todo{}, ErrNotFound
+	}
+
+	todos, err := s.List()
+	if err != nil {
+		return todo.Todo{}, err
+	}
+	prefix := strings.ToLower(ref)
+	var found []todo.Todo
+	for _, t := range todos {
+		if strings.HasPrefix(t.UUID, prefix) {
+			found = append(found, t)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return todo.Todo{}, ErrNotFound
+	case 1:
+		return found[0], nil
+	default:
+		return todo.Todo{}, ErrAmbiguous
+	}
+}
+
+// PurgeDeleted removes tombstones for todos deleted before cutoff and
+// returns how many were removed
+func PurgeDeleted(s Store, cutoff time.Time) (int, error) {
+	purged := 0
+	err := s.Update(func(tx Tx) error {
+		todos, err := tx.ListAll()
+		if err != nil {
+			return err
+		}
+		for _, t := range todos {
+			if t.Deleted() && t.DeletedAt.Before(cutoff) {
+				if err := tx.Purge(t.ID); err != nil {
+					return err
+				}
+				purged++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// getLive returns the todo with id from a backend's raw lookup, hiding
+// tombstones
+func getLive(t todo.Todo, err error) (todo.Todo, error) {
+	if err == nil && t.Deleted() {
+		return todo.Todo{}, ErrNotFound
+	}
+	return t, err
+}
+
+// decodeTodo reads a todo stored as JSON by the SQLite and Bolt backends.
+// Rows written before todos carried a UUID get their LegacyUUID.
+func decodeTodo(data []byte) (todo.Todo, error) {
+	var t todo.Todo
+	if err := json.Unmarshal(data, &t); err != nil {
+		return todo.Todo{}, err
+	}
+	t.EnsureUUID()
+	return t, nil
+}
+
+// stamp is applied by Put: it gives a todo being stored for the first time
+// its UUID and records when it was written
+func stamp(t todo.Todo) todo.Todo {
+	if t.UUID == "" {
+		t.UUID = todo.NewUUID()
+	}
+	now := time.Now().UTC()
+	t.UpdatedAt = &now
+	return t
+}
+
+// softDelete is Tx.Delete for backends built on Get and Put
+func softDelete(tx Tx, id int) error {
+	t, err := tx.Get(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	t.DeletedAt = &now
+	return tx.Put(t)
+}
+
+// sortByID orders todos the way List promises
+func sortByID(todos []todo.Todo) {
+	sort.Slice(todos, func(i, j int) bool { return todos[i].ID < todos[j].ID })
+}
+
+// runner is the part of a backend that the single-item helpers build on
+type runner interface {
+	View(fn func(Tx) error) error
+	Update(fn func(Tx) error) error
+}
+
+// oneShot implements Store's single-item methods as one transaction each
+type oneShot struct {
+	r runner
+}
+
+func (o oneShot) Get(id int) (todo.Todo, error) {
+	var t todo.Todo
+	err := o.r.View(func(tx Tx) error {
+		var err error
+		t, err = tx.Get(id)
+		return err
+	})
+	return t, err
+}
+
+func (o oneShot) List() ([]todo.Todo, error) {
+	var todos []todo.Todo
+	err := o.r.View(func(tx Tx) error {
+		var err error
+		todos, err = tx.List()
+		return err
+	})
+	return todos, err
+}
+
+func (o oneShot) Put(t todo.Todo) error {
+	return o.r.Update(func(tx Tx) error { return tx.Put(t) })
+}
+
+func (o oneShot) Delete(id int) error {
+	return o.r.Update(func(tx Tx) error { return tx.Delete(id) })
+}
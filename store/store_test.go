@@ -0,0 +1,277 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-bubbletea/todo"
+)
+
+// openBackend opens a new store of backend in a temporary directory and
+// returns it with a function that opens the same file again
+func openBackend(t *testing.T, backend string) (Store, func() Store) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultPath(backend))
+	open := func() Store {
+		t.Helper()
+		s, err := Open(backend, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+	return open(), open
+}
+
+func titles(todos []todo.Todo) string {
+	var names []string
+	for _, t := range todos {
+		names = append(names, fmt.Sprintf("%d %s", t.ID, t.Title))
+	}
+	return strings.Join(names, ", ")
+}
+
+func TestRoundTrip(t *testing.T) {
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	done := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	want := []todo.Todo{
+		{ID: 1, UUID: todo.NewUUID(), Title: "Milk", Description: "Oat", Priority: "high", Category: "shopping", DueDate: &due, CreatedAt: done},
+		{ID: 2, UUID: todo.NewUUID(), Title: "Report", Priority: "low", Completed: true, CompletedAt: &done, CreatedAt: done},
+	}
+
+	for _, backend := range Backends {
+		t.Run(backend, func(t *testing.T) {
+			s, reopen := openBackend(t, backend)
+			for _, item := range want {
+				if err := s.Put(item); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			s = reopen()
+			got, err := s.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("List = %s, want %s", titles(got), titles(want))
+			}
+			for i := range want {
+				if got[i].UpdatedAt == nil {
+					t.Errorf("#%d has no UpdatedAt", got[i].ID)
+				}
+				if !sameTodo(got[i], want[i]) {
+					t.Errorf("#%d read back as %+v, want %+v", want[i].ID, got[i], want[i])
+				}
+			}
+
+			err = s.View(func(tx Tx) error {
+				next, err := tx.NextID()
+				if err == nil && next != 3 {
+					t.Errorf("NextID = %d, want 3", next)
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDeleteKeepsTombstone(t *testing.T) {
+	for _, backend := range Backends {
+		t.Run(backend, func(t *testing.T) {
+			s, reopen := openBackend(t, backend)
+			if err := s.Put(todo.Todo{ID: 1, Title: "Milk"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Delete(1); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Delete(1); !errors.Is(err, ErrNotFound) {
+				t.Errorf("deleting twice: err = %v, want ErrNotFound", err)
+			}
+			s.Close()
+
+			s = reopen()
+			if _, err := s.Get(1); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get of a deleted todo: err = %v, want ErrNotFound", err)
+			}
+			all, err := SnapshotAll(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all.Todos) != 1 || !all.Todos[0].Deleted() {
+				t.Fatalf("SnapshotAll = %+v, want one tombstone", all.Todos)
+			}
+
+			if n, err := PurgeDeleted(s, time.Now().Add(time.Minute)); err != nil || n != 1 {
+				t.Fatalf("PurgeDeleted = %d, %v, want 1", n, err)
+			}
+			if all, _ = SnapshotAll(s); len(all.Todos) != 0 {
+				t.Errorf("purged tombstone is still there: %+v", all.Todos)
+			}
+			if next, _ := nextID(s); next != 2 {
+				t.Errorf("NextID after a purge = %d, want 2", next)
+			}
+		})
+	}
+}
+
+func nextID(s Store) (int, error) {
+	var next int
+	err := s.View(func(tx Tx) error {
+		var err error
+		next, err = tx.NextID()
+		return err
+	})
+	return next, err
+}
+
+func TestCompareAndReplace(t *testing.T) {
+	for _, backend := range Backends {
+		t.Run(backend, func(t *testing.T) {
+			s, _ := openBackend(t, backend)
+			milk := todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Milk"}
+			if err := s.Put(milk); err != nil {
+				t.Fatal(err)
+			}
+			old, err := SnapshotAll(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			list := &todo.TodoList{Todos: []todo.Todo{{ID: 4, UUID: todo.NewUUID(), Title: "Bread"}}, NextID: 5}
+			if err := CompareAndReplace(s, old, list); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := s.List()
+			if titles(got) != "4 Bread" {
+				t.Errorf("List after Replace = %s, want 4 Bread", titles(got))
+			}
+			all, _ := SnapshotAll(s)
+			if len(all.Todos) != 2 || !all.Todos[0].Deleted() {
+				t.Errorf("the dropped todo was not kept as a tombstone: %+v", all.Todos)
+			}
+			if next, _ := nextID(s); next != 5 {
+				t.Errorf("NextID = %d, want 5", next)
+			}
+
+			if err := CompareAndReplace(s, old, list); !errors.Is(err, ErrChanged) {
+				t.Errorf("replacing a stale snapshot: err = %v, want ErrChanged", err)
+			}
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+	for _, backend := range Backends {
+		t.Run(backend, func(t *testing.T) {
+			src, _ := openBackend(t, BackendJSON)
+			for i, title := range []string{"Milk", "Bread"} {
+				if err := src.Put(todo.Todo{ID: i + 1, UUID: todo.NewUUID(), Title: title}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			copied, _ := openBackend(t, backend)
+			if n, err := Copy(copied, src); err != nil || n != 2 {
+				t.Fatalf("Copy = %d, %v, want 2", n, err)
+			}
+			if got, _ := copied.List(); titles(got) != "1 Milk, 2 Bread" {
+				t.Errorf("copy holds %s", titles(got))
+			}
+			if _, err := Copy(copied, src); err == nil {
+				t.Error("Copy into a store that holds todos succeeded")
+			}
+		})
+	}
+}
+
+func TestMove(t *testing.T) {
+	for _, backend := range Backends {
+		t.Run(backend, func(t *testing.T) {
+			src, _ := openBackend(t, backend)
+			dst, _ := openBackend(t, BackendJSON)
+			milk := todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Milk"}
+			if err := src.Put(milk); err != nil {
+				t.Fatal(err)
+			}
+			if err := dst.Put(todo.Todo{ID: 1, UUID: todo.NewUUID(), Title: "Bread"}); err != nil {
+				t.Fatal(err)
+			}
+
+			moved, err := Move(dst, src, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if moved.ID != 2 || moved.UUID == milk.UUID {
+				t.Errorf("moved todo is #%d %s, want #2 with a new UUID", moved.ID, moved.UUID)
+			}
+			if got, _ := src.List(); len(got) != 0 {
+				t.Errorf("source still holds %s", titles(got))
+			}
+			if got, _ := dst.List(); titles(got) != "1 Bread, 2 Milk" {
+				t.Errorf("destination holds %s", titles(got))
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	s, _ := openBackend(t, BackendJSON)
+	for i, uuid := range []string{"0aa1", "0aa2", "0bb3", "7cc4"} {
+		if err := s.Put(todo.Todo{ID: i + 1, UUID: uuid, Title: uuid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Put(todo.Todo{ID: 7, UUID: "0ee7", Title: "0ee7"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(7); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+		err  error
+	}{
+		{"2", "0aa2", nil},
+		{"0bb", "0bb3", nil},
+		{"0AA1", "0aa1", nil},
+		{"0aa", "", ErrAmbiguous},
+		{"9", "", ErrNotFound},
+		// #7 is deleted, and must not fall back on the UUID starting 7
+		{"7", "", ErrNotFound},
+		{"7c", "7cc4", nil},
+		{"", "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(s, tt.ref)
+		if !errors.Is(err, tt.err) || err == nil && got.Title != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q, %v", tt.ref, got.Title, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestBackendForPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"todos.json": BackendJSON, "a/b.DB": BackendSQLite, "x.sqlite3": BackendSQLite,
+		"x.bolt": BackendBolt, "x.bbolt": BackendBolt, "x.txt": "",
+	} {
+		if got := BackendForPath(path); got != want {
+			t.Errorf("BackendForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrLocked is returned when another process holds the lock on a todo file
+var ErrLocked = errors.New("todo file is locked by another process")
+
+// errWouldBlock is returned by tryLock when the lock is already held
+var errWouldBlock = errors.New("lock is held")
+
+// lockWait is how long Lock keeps retrying before giving up
+const lockWait = 2 * time.Second
+
+// FileLock is an advisory lock on a todo file. It is held on a sibling
+// ".lock" file so the data file itself can be replaced by rename.
+type FileLock struct {
+	f *os.File
+}
+
+// LockPath returns the lock file used to guard path
+func LockPath(path string) string {
+	return path + ".lock"
+}
+
+// Lock takes the exclusive lock guarding path, waiting briefly if another
+// process holds it. Load and Save do not lock on their own; callers hold
+// the lock across the whole read-modify-write cycle.
+func Lock(path string) (*FileLock, error) {
+	f, err := os.OpenFile(LockPath(path), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(lockWait)
+	for {
+		err := tryLock(f)
+		if err == nil {
+			return &FileLock{f: f}, nil
+		}
+		if !errors.Is(err, errWouldBlock) {
+			f.Close()
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w: %s (lock file %s)", ErrLocked, path, LockPath(path))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Unlock releases the lock
+func (l *FileLock) Unlock() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
+
+// Update loads the list at path, passes it to fn and saves the result,
+// holding the file lock throughout. Nothing is written if fn fails.
+func Update(path string, fn func(*TodoList) error) error {
+	lock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	todoList, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(todoList); err != nil {
+		return err
+	}
+	return Save(path, todoList)
+}
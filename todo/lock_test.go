@@ -0,0 +1,83 @@
+//go:build unix || windows
+
+package todo
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestLockExcludes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	lock, err := Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Lock(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("second Lock: err = %v, want ErrLocked", err)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Errorf("second Unlock: %v", err)
+	}
+	again, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock after Unlock: %v", err)
+	}
+	again.Unlock()
+}
+
+func TestUpdateSerialises(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	const workers = 8
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- Update(path, func(list *TodoList) error {
+				list.Todos = append(list.Todos, Todo{ID: list.NextID})
+				list.NextID++
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Todos) != workers || list.NextID != workers+1 {
+		t.Errorf("%d todos and next ID %d, want %d and %d: updates were lost", len(list.Todos), list.NextID, workers, workers+1)
+	}
+}
+
+func TestUpdateFailureWritesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	stop := errors.New("stop")
+	err := Update(path, func(list *TodoList) error {
+		list.Todos = append(list.Todos, Todo{ID: 1})
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("err = %v, want fn's error", err)
+	}
+	if list, _ := Load(path); len(list.Todos) != 0 {
+		t.Errorf("a failed update wrote %+v", list.Todos)
+	}
+}
@@ -0,0 +1,162 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CurrentVersion is the on-disk format version written by Save.
+//
+// Version history:
+//
+//	1  unversioned files written before the format carried a version field
+//	2  unified schema: every todo has a priority, next_id is past every ID
+//	3  every todo has a uuid, and deleted todos stay in the file with
+//	   deleted_at set until purged
+const CurrentVersion = 3
+
+// ErrNewerVersion is returned when a file was written by a newer release
+var ErrNewerVersion = errors.New("todo file was written by a newer version")
+
+// migration upgrades a decoded document by exactly one version
+type migration func(doc map[string]any) error
+
+// migrations maps a version to the step that upgrades it to version+1
+var migrations = map[int]migration{
+	1: migrateV1ToV2,
+	2: migrateV2ToV3,
+}
+
+// fileVersion reports the format version of a decoded document.
+// Files without a version field predate versioning and count as version 1.
+func fileVersion(doc map[string]any) int {
+	v, ok := doc["version"].(float64)
+	if !ok || v < 1 {
+		return 1
+	}
+	return int(v)
+}
+
+// migrate upgrades doc in place to CurrentVersion and reports the
+// version it started from
+func migrate(doc map[string]any) (int, error) {
+	from := fileVersion(doc)
+	if from > CurrentVersion {
+		return from, fmt.Errorf("%w (file version %d, supported %d)", ErrNewerVersion, from, CurrentVersion)
+	}
+
+	for v := from; v < CurrentVersion; v++ {
+		step, ok := migrations[v]
+		if !ok {
+			return from, fmt.Errorf("no migration from version %d", v)
+		}
+		if err := step(doc); err != nil {
+			return from, fmt.Errorf("migrating from version %d: %w", v, err)
+		}
+		doc["version"] = v + 1
+	}
+
+	return from, nil
+}
+
+// BackupPath returns where the pre-migration copy of a version is kept
+func BackupPath(path string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, version)
+}
+
+// writeBackup copies the original bytes aside before a migration.
+// An existing backup is left alone so the oldest copy survives retries.
+func writeBackup(path string, version int, data []byte) error {
+	backup := BackupPath(path, version)
+	if _, err := os.Stat(backup); err == nil {
+		return nil
+	}
+	return WriteFileAtomic(backup, data, 0600)
+}
+
+// migrateV1ToV2 fills in the defaults the TUI assumed for todos written by
+// the basic CLI and repairs next_id so new todos never reuse an ID.
+func migrateV1ToV2(doc map[string]any) error {
+	todos, _ := doc["todos"].([]any)
+	maxID := 0
+	for _, raw := range todos {
+		t, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("malformed todo entry")
+		}
+		if p, _ := t["priority"].(string); p == "" {
+			t["priority"] = "low"
+		}
+		if _, ok := t["category"]; !ok {
+			t["category"] = ""
+		}
+		if id, ok := t["id"].(float64); ok && int(id) > maxID {
+			maxID = int(id)
+		}
+	}
+	if todos == nil {
+		doc["todos"] = []any{}
+	}
+
+	nextID, _ := doc["next_id"].(float64)
+	if int(nextID) <= maxID {
+		doc["next_id"] = maxID + 1
+	}
+
+	return nil
+}
+
+// migrateV2ToV3 gives every todo its LegacyUUID. Version 2 had no
+// tombstones, so there are none to convert; the version still changes so
+// that releases which would show tombstones as ordinary todos refuse the
+// file.
+func migrateV2ToV3(doc map[string]any) error {
+	todos, _ := doc["todos"].([]any)
+	for _, raw := range todos {
+		t, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("malformed todo entry")
+		}
+		if id, _ := t["uuid"].(string); id != "" {
+			continue
+		}
+		var item Todo
+		data, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &item); err != nil {
+			return fmt.Errorf("todo %v: %w", t["id"], err)
+		}
+		t["uuid"] = LegacyUUID(item)
+	}
+	return nil
+}
+
+// decode parses raw file contents, running any pending migrations. It
+// reports the version the data was stored in.
+func decode(data []byte) (*TodoList, int, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+
+	from, err := migrate(doc)
+	if err != nil {
+		return nil, from, err
+	}
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, from, err
+	}
+
+	var todoList TodoList
+	if err := json.Unmarshal(upgraded, &todoList); err != nil {
+		return nil, from, err
+	}
+
+	return &todoList, from, nil
+}
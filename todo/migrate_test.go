@@ -0,0 +1,144 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// v1File is a todo file as the basic CLI wrote it before the format had a
+// version: no priority or category, and a next_id behind the IDs in use
+const v1File = `{
+  "todos": [
+    {"id": 1, "title": "Milk", "completed": false, "created_at": "2025-03-01T10:00:00Z"},
+    {"id": 5, "title": "Report", "completed": true, "created_at": "2025-03-02T10:00:00Z", "priority": "high", "category": "work"}
+  ],
+  "next_id": 2
+}`
+
+func TestLoadMigratesV1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	if err := os.WriteFile(path, []byte(v1File), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", list.Version, CurrentVersion)
+	}
+	if list.NextID != 6 {
+		t.Errorf("NextID = %d, want 6", list.NextID)
+	}
+	milk, report := list.Todos[0], list.Todos[1]
+	if milk.Priority != "low" || report.Priority != "high" || report.Category != "work" {
+		t.Errorf("defaults not filled in: %+v", list.Todos)
+	}
+	if milk.UUID != LegacyUUID(milk) {
+		t.Errorf("UUID = %q, want the legacy UUID %q", milk.UUID, LegacyUUID(milk))
+	}
+
+	backup, err := os.ReadFile(BackupPath(path, 1))
+	if err != nil {
+		t.Fatalf("no backup of the version 1 file: %v", err)
+	}
+	if string(backup) != v1File {
+		t.Error("the backup does not hold the original file")
+	}
+	if info, err := os.Stat(BackupPath(path, 1)); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("backup has mode %v, want -rw-------", info.Mode().Perm())
+	}
+
+	var saved struct{ Version int }
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Version != CurrentVersion {
+		t.Errorf("migrated file was not written back: version %d, %v", saved.Version, err)
+	}
+
+	// The UUIDs must not change when the file is read again
+	again, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Todos[0].UUID != milk.UUID {
+		t.Errorf("UUID changed from %q to %q", milk.UUID, again.Todos[0].UUID)
+	}
+}
+
+// v2File is a version 2 file: one todo from before UUIDs and one that
+// already has one
+const v2File = `{
+  "version": 2,
+  "todos": [
+    {"id": 1, "title": "Milk", "completed": false, "created_at": "2025-03-01T10:00:00Z", "priority": "low", "category": ""},
+    {"id": 2, "uuid": "01a14537-770b-7d4b-97c3-ff55bd5d4851", "title": "Bread", "completed": false, "created_at": "2025-03-02T10:00:00Z", "priority": "low", "category": ""}
+  ],
+  "next_id": 3
+}`
+
+func TestMigrateV2GivesUUIDs(t *testing.T) {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(v2File), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateV2ToV3(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	todos := doc["todos"].([]any)
+	milk, bread := todos[0].(map[string]any), todos[1].(map[string]any)
+	want := LegacyUUID(Todo{ID: 1, CreatedAt: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)})
+	if milk["uuid"] != want {
+		t.Errorf("migrated UUID = %v, want the legacy UUID %s", milk["uuid"], want)
+	}
+	if bread["uuid"] != "01a14537-770b-7d4b-97c3-ff55bd5d4851" {
+		t.Errorf("existing UUID replaced with %v", bread["uuid"])
+	}
+}
+
+func TestReadDoesNotWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	if err := os.WriteFile(path, []byte(v1File), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.NextID != 6 {
+		t.Errorf("NextID = %d, want 6", list.NextID)
+	}
+	if data, _ := os.ReadFile(path); string(data) != v1File {
+		t.Error("Read rewrote the file")
+	}
+	if _, err := os.Stat(BackupPath(path, 1)); !os.IsNotExist(err) {
+		t.Error("Read wrote a migration backup")
+	}
+}
+
+func TestLoadNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "todos": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("err = %v, want ErrNewerVersion", err)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	list, err := Load(filepath.Join(t.TempDir(), "todos.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Todos) != 0 || list.NextID != 1 {
+		t.Errorf("Load of a missing file = %+v, want an empty list", list)
+	}
+}
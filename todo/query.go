@@ -0,0 +1,277 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayout is how dates are written in queries
+const dateLayout = "2006-01-02"
+
+// Query is a parsed filter expression. It is a list of terms separated by
+// spaces, all of which must match:
+//
+//	status:pending        pending, done or all
+//	priority:high         also priority>=medium and the other comparisons
+//	category:work         case-insensitive; category:none for no category
+//	due<2026-11-01        also due:, <=, > and >=; due:none and due:any
+//	created>=2026-01-01   when the todo was added
+//	id>10                 by ID
+//	title:report          words in the title only
+//	"text search"         anything else matches title, description or category
+//
+// Dates may also be today, tomorrow or yesterday. A leading - negates a
+// term, as in -category:work. The empty query matches every todo.
+type Query struct {
+	terms []func(Todo) bool
+}
+
+// queryFields are the field names recognised before ':' or a comparison.
+// Words with any other prefix are searched for as text.
+var queryFields = map[string]func(op, value string) (func(Todo) bool, error){
+	"status":   statusTerm,
+	"priority": priorityTerm,
+	"category": categoryTerm,
+	"due":      dueTerm,
+	"created":  createdTerm,
+	"id":       idTerm,
+	"title":    titleTerm,
+}
+
+// QueryFields lists the field names a query understands
+var QueryFields = []string{"status", "priority", "category", "due", "created", "id", "title"}
+
+// ParseQuery parses a filter expression
+func ParseQuery(s string) (Query, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return Query{}, err
+	}
+
+	var q Query
+	for _, token := range tokens {
+		match, err := parseTerm(token)
+		if err != nil {
+			return Query{}, err
+		}
+		q.terms = append(q.terms, match)
+	}
+	return q, nil
+}
+
+// Empty reports whether the query matches every todo
+func (q Query) Empty() bool {
+	return len(q.terms) == 0
+}
+
+// Match reports whether t matches every term of the query
+func (q Query) Match(t Todo) bool {
+	for _, match := range q.terms {
+		if !match(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the todos that match the query, in their original order
+func (q Query) Filter(todos []Todo) []Todo {
+	matched := make([]Todo, 0, len(todos))
+	for _, t := range todos {
+		if q.Match(t) {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
+
+// tokenize splits s on spaces outside double quotes and removes the quotes
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	var b strings.Builder
+	inQuotes, inToken := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			inToken = true
+		case (r == ' ' || r == '\t' || r == '\n') && !inQuotes:
+			if inToken {
+				tokens = append(tokens, b.String())
+				b.Reset()
+				inToken = false
+			}
+		default:
+			b.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inToken {
+		tokens = append(tokens, b.String())
+	}
+	return tokens, nil
+}
+
+// parseTerm turns one token into a matcher
+func parseTerm(token string) (func(Todo) bool, error) {
+	negate := false
+	if len(token) > 1 && strings.HasPrefix(token, "-") {
+		negate = true
+		token = token[1:]
+	}
+
+	match := textTerm(token)
+	if i := strings.IndexAny(token, ":<>"); i > 0 {
+		if build, ok := queryFields[strings.ToLower(token[:i])]; ok {
+			op, value := splitOp(token[i:])
+			var err error
+			if match, err = build(op, value); err != nil {
+				return nil, fmt.Errorf("%s: %w", token, err)
+			}
+		}
+	}
+
+	if negate {
+		return func(t Todo) bool { return !match(t) }, nil
+	}
+	return match, nil
+}
+
+// splitOp separates the operator at the start of s from the value after it
+func splitOp(s string) (string, string) {
+	for _, op := range []string{"<=", ">=", ":", "<", ">"} {
+		if strings.HasPrefix(s, op) {
+			return op, s[len(op):]
+		}
+	}
+	return "", s
+}
+
+// compare applies op to the result of comparing a value with the query's,
+// where cmp is negative, zero or positive as for strings.Compare
+func compare(op string, cmp int) bool {
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+func textTerm(text string) func(Todo) bool {
+	text = strings.ToLower(text)
+	return func(t Todo) bool {
+		return strings.Contains(strings.ToLower(t.Title), text) ||
+			strings.Contains(strings.ToLower(t.Description), text) ||
+			strings.Contains(strings.ToLower(t.Category), text)
+	}
+}
+
+func statusTerm(op, value string) (func(Todo) bool, error) {
+	if op != ":" {
+		return nil, fmt.Errorf("status only supports ':'")
+	}
+	switch strings.ToLower(value) {
+	case "pending", "open", "todo":
+		return func(t Todo) bool { return !t.Completed }, nil
+	case "done", "completed", "complete":
+		return func(t Todo) bool { return t.Completed }, nil
+	case "all", "any":
+		return func(Todo) bool { return true }, nil
+	}
+	return nil, fmt.Errorf("unknown status %q (want pending, done or all)", value)
+}
+
+func priorityTerm(op, value string) (func(Todo) bool, error) {
+	rank, ok := priorityRank[strings.ToLower(value)]
+	if !ok {
+		return nil, fmt.Errorf("unknown priority %q (want low, medium or high)", value)
+	}
+	return func(t Todo) bool { return compare(op, priorityRank[t.Priority]-rank) }, nil
+}
+
+func categoryTerm(op, value string) (func(Todo) bool, error) {
+	if op != ":" {
+		return nil, fmt.Errorf("category only supports ':'")
+	}
+	if strings.EqualFold(value, "none") {
+		return func(t Todo) bool { return t.Category == "" }, nil
+	}
+	return func(t Todo) bool { return strings.EqualFold(t.Category, value) }, nil
+}
+
+func dueTerm(op, value string) (func(Todo) bool, error) {
+	switch strings.ToLower(value) {
+	case "none":
+		if op == ":" {
+			return func(t Todo) bool { return t.DueDate == nil }, nil
+		}
+	case "any":
+		if op == ":" {
+			return func(t Todo) bool { return t.DueDate != nil }, nil
+		}
+	}
+
+	day, err := parseDay(value)
+	if err != nil {
+		return nil, err
+	}
+	return func(t Todo) bool {
+		return t.DueDate != nil && compare(op, strings.Compare(t.DueDate.Format(dateLayout), day))
+	}, nil
+}
+
+func createdTerm(op, value string) (func(Todo) bool, error) {
+	day, err := parseDay(value)
+	if err != nil {
+		return nil, err
+	}
+	return func(t Todo) bool {
+		return compare(op, strings.Compare(t.CreatedAt.Format(dateLayout), day))
+	}, nil
+}
+
+func idTerm(op, value string) (func(Todo) bool, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID %q", value)
+	}
+	return func(t Todo) bool { return compare(op, t.ID-id) }, nil
+}
+
+func titleTerm(op, value string) (func(Todo) bool, error) {
+	if op != ":" {
+		return nil, fmt.Errorf("title only supports ':'")
+	}
+	value = strings.ToLower(value)
+	return func(t Todo) bool { return strings.Contains(strings.ToLower(t.Title), value) }, nil
+}
+
+// parseDay normalises a query date to YYYY-MM-DD so days compare as strings
+func parseDay(value string) (string, error) {
+	now := time.Now()
+	switch strings.ToLower(value) {
+	case "today":
+		return now.Format(dateLayout), nil
+	case "tomorrow":
+		return now.AddDate(0, 0, 1).Format(dateLayout), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1).Format(dateLayout), nil
+	}
+	day, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q (use YYYY-MM-DD, today, tomorrow or yesterday)", value)
+	}
+	return day.Format(dateLayout), nil
+}
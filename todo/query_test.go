@@ -0,0 +1,127 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	day := func(s string) *time.Time {
+		d, _ := time.Parse(dateLayout, s)
+		return &d
+	}
+	created, _ := time.Parse(dateLayout, "2026-01-15")
+	todos := []Todo{
+		{ID: 1, Title: "Write report", Priority: "high", Category: "Work", DueDate: day("2026-10-20"), CreatedAt: created},
+		{ID: 2, Title: "Buy milk", Description: "oat, not soy", Priority: "low", Category: "shopping", Completed: true, CreatedAt: created.AddDate(0, 3, 0)},
+		{ID: 3, Title: "Book flights", Priority: "medium", DueDate: day("2026-11-01"), CreatedAt: created.AddDate(0, 6, 0)},
+		{ID: 12, Title: "Call about the report", Priority: "low", Category: "work", CreatedAt: created.AddDate(0, 9, 0)},
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{1, 2, 3, 12}},
+		{"status:pending", []int{1, 3, 12}},
+		{"status:done", []int{2}},
+		{"status:all", []int{1, 2, 3, 12}},
+		{"priority:high", []int{1}},
+		{"priority>=medium", []int{1, 3}},
+		{"priority<high", []int{2, 3, 12}},
+		{"category:work", []int{1, 12}},
+		{"category:WORK", []int{1, 12}},
+		{"category:none", []int{3}},
+		{"-category:work", []int{2, 3}},
+		{"due:none", []int{2, 12}},
+		{"due:any", []int{1, 3}},
+		{"due<2026-11-01", []int{1}},
+		{"due<=2026-11-01", []int{1, 3}},
+		{"due:2026-10-20", []int{1}},
+		{"created>=2026-07-15", []int{3, 12}},
+		{"id>10", []int{12}},
+		{"id:2", []int{2}},
+		{"title:report", []int{1, 12}},
+		{"report", []int{1, 12}},
+		{"oat", []int{2}},
+		{"SHOPPING", []int{2}},
+		{`"about the"`, []int{12}},
+		{"report status:pending -id:12", []int{1}},
+		{"unknown:field", nil},
+		{"-", nil},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tt.query, err)
+			continue
+		}
+		var got []int
+		for _, todo := range q.Filter(todos) {
+			got = append(got, todo.ID)
+		}
+		if !equalIDs(got, tt.want) {
+			t.Errorf("%q matched %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	if q, _ := ParseQuery("  "); !q.Empty() {
+		t.Error("a blank query is not empty")
+	}
+}
+
+func TestQueryRelativeDates(t *testing.T) {
+	now := time.Now()
+	yesterday, tomorrow := now.AddDate(0, 0, -1), now.AddDate(0, 0, 1)
+	todos := []Todo{{ID: 1, DueDate: &yesterday}, {ID: 2, DueDate: &now}, {ID: 3, DueDate: &tomorrow}}
+
+	for query, want := range map[string][]int{
+		"due<today":     {1},
+		"due:today":     {2},
+		"due:tomorrow":  {3},
+		"due<=tomorrow": {1, 2, 3},
+		"due:yesterday": {1},
+	} {
+		q, err := ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		for _, todo := range q.Filter(todos) {
+			got = append(got, todo.ID)
+		}
+		if !equalIDs(got, want) {
+			t.Errorf("%q matched %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		`"unterminated`,
+		"status:maybe",
+		"status>done",
+		"priority:urgent",
+		"category>work",
+		"due<soon",
+		"created:2026-13-01",
+		"id:abc",
+		"title<x",
+	} {
+		if _, err := ParseQuery(query); err == nil {
+			t.Errorf("ParseQuery(%q) succeeded", query)
+		}
+	}
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
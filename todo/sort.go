@@ -0,0 +1,92 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Orders accepted by Sort
+const (
+	OrderID       = "id"
+	OrderPriority = "priority"
+	OrderDue      = "due"
+	OrderCreated  = "created"
+	OrderTitle    = "title"
+)
+
+// Orders lists every order accepted by Sort
+var Orders = []string{OrderID, OrderPriority, OrderDue, OrderCreated, OrderTitle}
+
+// priorityRank orders priorities from lowest to highest; unknown values
+// rank below low
+var priorityRank = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// Sort orders todos in place:
+//
+//   - id: by ID
+//   - priority: pending before completed, then high to low priority, then
+//     earliest due date, then newest first
+//   - due: earliest due date first, todos without one last
+//   - created: oldest first
+//   - title: alphabetically, ignoring case
+//
+// Ties keep their existing order.
+func Sort(todos []Todo, order string) error {
+	var less func(a, b Todo) bool
+	switch order {
+	case OrderID, "":
+		less = func(a, b Todo) bool { return a.ID < b.ID }
+	case OrderPriority:
+		less = byPriority
+	case OrderDue:
+		less = byDue
+	case OrderCreated:
+		less = func(a, b Todo) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case OrderTitle:
+		less = func(a, b Todo) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) }
+	default:
+		return fmt.Errorf("unknown sort order %q (want one of %s)", order, strings.Join(Orders, ", "))
+	}
+
+	sort.SliceStable(todos, func(i, j int) bool { return less(todos[i], todos[j]) })
+	return nil
+}
+
+// byPriority is the TUI's default ordering
+func byPriority(a, b Todo) bool {
+	// First by completion status (incomplete first)
+	if a.Completed != b.Completed {
+		return !a.Completed
+	}
+
+	// Then by priority (high to low)
+	if priorityRank[a.Priority] != priorityRank[b.Priority] {
+		return priorityRank[a.Priority] > priorityRank[b.Priority]
+	}
+
+	// Then by due date (earliest first)
+	if a.DueDate != nil && b.DueDate != nil {
+		return a.DueDate.Before(*b.DueDate)
+	}
+	if a.DueDate != nil {
+		return true
+	}
+	if b.DueDate != nil {
+		return false
+	}
+
+	// Finally by creation date (newest first)
+	return a.CreatedAt.After(b.CreatedAt)
+}
+
+func byDue(a, b Todo) bool {
+	switch {
+	case a.DueDate == nil:
+		return false
+	case b.DueDate == nil:
+		return true
+	default:
+		return a.DueDate.Before(*b.DueDate)
+	}
+}
@@ -0,0 +1,147 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultFile is the storage file used when no other path is given
+const DefaultFile = "todos.json"
+
+// Load reads a todo list from path. A missing file yields an empty list.
+// Files in an older format are backed up, migrated and written back.
+func Load(path string) (*TodoList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewTodoList(), nil
+		}
+		return nil, err
+	}
+
+	todoList, from, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if from < CurrentVersion {
+		if err := writeBackup(path, from, data); err != nil {
+			return nil, fmt.Errorf("backing up before migration: %w", err)
+		}
+		if err := Save(path, todoList); err != nil {
+			return nil, fmt.Errorf("saving migrated file: %w", err)
+		}
+	}
+
+	return todoList, nil
+}
+
+// Read parses the todo list at path, migrating it in memory only. Unlike
+// Load it never writes, which makes it safe for opening backups.
+func Read(path string) (*TodoList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	todoList, _, err := parse(data)
+	return todoList, err
+}
+
+// parse decodes file contents and fills in defaults for missing fields
+func parse(data []byte) (*TodoList, int, error) {
+	todoList, from, err := decode(data)
+	if err != nil {
+		return nil, from, err
+	}
+
+	if todoList.Todos == nil {
+		todoList.Todos = []Todo{}
+	}
+	for i := range todoList.Todos {
+		todoList.Todos[i].EnsureUUID()
+	}
+	if todoList.NextID < 1 {
+		todoList.NextID = 1
+	}
+
+	return todoList, from, nil
+}
+
+// Save writes a todo list to path as indented JSON in the current format.
+// The file is replaced atomically, so readers see either the old or the
+// new contents, never a truncated file. The replaced contents are kept
+// at BackupFile(path).
+func Save(path string, todoList *TodoList) error {
+	todoList.Version = CurrentVersion
+	data, err := json.MarshalIndent(todoList, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	keepPrevious(path)
+	return WriteFileAtomic(path, data, 0600)
+}
+
+// BackupFile returns where Save keeps the previous contents of path
+func BackupFile(path string) string {
+	return path + ".bak"
+}
+
+// keepPrevious hard-links the current file to its backup name. The rename
+// in WriteFileAtomic then leaves the old contents reachable only through
+// the backup. This is best effort; filesystems without hard links simply
+// get no rolling backup.
+func keepPrevious(path string) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	backup := BackupFile(path)
+	os.Remove(backup)
+	os.Link(path, backup)
+}
+
+// Backups lists the backup copies of path that exist on disk, newest first
+func Backups(path string) ([]string, error) {
+	var found []string
+	for _, pattern := range []string{
+		BackupFile(path),
+		path + ".v*.bak",
+		path + ".corrupt-*",
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, matches...)
+	}
+
+	modTimes := make(map[string]time.Time, len(found))
+	for _, name := range found {
+		if info, err := os.Stat(name); err == nil {
+			modTimes[name] = info.ModTime()
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return modTimes[found[i]].After(modTimes[found[j]])
+	})
+
+	return found, nil
+}
+
+// SetAside renames an unreadable file out of the way so a fresh list can
+// be written in its place, and returns the name it was moved to.
+func SetAside(path string) (string, error) {
+	aside := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(path, aside); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return aside, nil
+}
@@ -0,0 +1,47 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSaveKeepsPrevious(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	first := &TodoList{Todos: []Todo{{ID: 1, Title: "Milk"}}, NextID: 2}
+	if err := Save(path, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, &TodoList{Todos: []Todo{}, NextID: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := Read(BackupFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(previous.Todos) != 1 || previous.Todos[0].Title != "Milk" {
+		t.Errorf("backup holds %+v, want the first save", previous.Todos)
+	}
+	if backups, _ := Backups(path); len(backups) != 1 {
+		t.Errorf("Backups = %v, want the one backup", backups)
+	}
+}
+
+func TestSaveIsPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions")
+	}
+	path := filepath.Join(t.TempDir(), "todos.json")
+	if err := Save(path, &TodoList{Todos: []Todo{}, NextID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("saved with mode %v, want -rw-------", mode)
+	}
+}
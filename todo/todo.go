@@ -0,0 +1,52 @@
+// Package todo holds the todo model shared by the basic CLI and the
+// Bubble Tea TUI, together with the code that reads and writes it to disk.
+package todo
+
+import "time"
+
+// Todo represents a single todo item. UUID identifies it everywhere; ID is
+// the short number shown and typed locally. UpdatedAt is when the todo was
+// last written to the store holding it, which the store sets itself.
+type Todo struct {
+	ID          int        `json:"id" yaml:"id"`
+	UUID        string     `json:"uuid" yaml:"uuid"`
+	Title       string     `json:"title" yaml:"title"`
+	Description string     `json:"description" yaml:"description"`
+	Completed   bool       `json:"completed" yaml:"completed"`
+	CreatedAt   time.Time  `json:"created_at" yaml:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" yaml:"completed_at,omitempty"`
+	Priority    string     `json:"priority" yaml:"priority"`
+	Category    string     `json:"category" yaml:"category"`
+	DueDate     *time.Time `json:"due_date,omitempty" yaml:"due_date,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" yaml:"deleted_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" yaml:"updated_at,omitempty"`
+}
+
+// Deleted reports whether t is a tombstone: a deleted todo kept so that
+// sync can pass the deletion on
+func (t Todo) Deleted() bool {
+	return t.DeletedAt != nil
+}
+
+// Live returns the todos that are not tombstones
+func Live(todos []Todo) []Todo {
+	kept := make([]Todo, 0, len(todos))
+	for _, t := range todos {
+		if !t.Deleted() {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// TodoList represents a collection of todos
+type TodoList struct {
+	Version int    `json:"version"`
+	Todos   []Todo `json:"todos"`
+	NextID  int    `json:"next_id"`
+}
+
+// NewTodoList returns an empty list ready for its first todo
+func NewTodoList() *TodoList {
+	return &TodoList{Version: CurrentVersion, Todos: []Todo{}, NextID: 1}
+}
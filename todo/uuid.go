@@ -0,0 +1,37 @@
+package todo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// legacyNamespace seeds the UUIDs derived for todos written before todos
+// carried one
+var legacyNamespace = uuid.MustParse("5f0c6a52-3d57-4b0e-9a43-2c1f7d6e8b90")
+
+// NewUUID returns the identity for a new todo. Version 7 UUIDs start with
+// the creation time, so they sort roughly in the order todos were added.
+func NewUUID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// LegacyUUID derives an identity for a todo written before todos carried
+// one. It depends only on the ID and creation time, so every copy of the
+// same todo gets the same UUID however it is read.
+func LegacyUUID(t Todo) string {
+	key := fmt.Sprintf("%d/%s", t.ID, t.CreatedAt.UTC().Format(time.RFC3339Nano))
+	return uuid.NewSHA1(legacyNamespace, []byte(key)).String()
+}
+
+// EnsureUUID gives t its legacy UUID if it has none
+func (t *Todo) EnsureUUID() {
+	if t.UUID == "" {
+		t.UUID = LegacyUUID(*t)
+	}
+}